@@ -0,0 +1,57 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// handleSetAppName 处理 \setappname [NAME]：修改当前会话的 application_name（显示在 pg_stat_activity 中），
+// 无参数时显示当前值
+func (c *CLI) handleSetAppName(name string) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		fmt.Fprintf(c.term, "Application name is \"%s\".\n", c.config.ApplicationName)
+		return
+	}
+	if err := c.setApplicationName(name); err != nil {
+		c.printError(err)
+		return
+	}
+	fmt.Fprintf(c.term, "Application name set to \"%s\".\n", c.config.ApplicationName)
+}
+
+// setApplicationName 在当前会话连接上执行 SET application_name，并记入 Config.ApplicationName，
+// \c 重新连接后继续使用；服务器会截断过长的名称并替换非 ASCII 字符，记录的是服务器实际使用的值
+func (c *CLI) setApplicationName(name string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if _, err := c.conn.ExecContext(ctx, "SET application_name = "+pq.QuoteLiteral(name)); err != nil {
+		return err
+	}
+	c.syncApplicationName("SET application_name")
+	return nil
+}
+
+// syncApplicationName 在 SET/RESET application_name 执行成功后读取服务器的当前值，
+// 使 \conninfo 与重新连接使用新的名称；SET LOCAL 只在当前事务内有效，不同步
+func (c *CLI) syncApplicationName(sqlStr string) {
+	words := topLevelWords(stripLeadingComments(sqlStr))
+	if len(words) < 2 || (words[0] != "SET" && words[0] != "RESET") || words[1] == "LOCAL" {
+		return
+	}
+	if !strings.Contains(strings.ToLower(sqlStr), "application_name") {
+		return
+	}
+
+	var name string
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := c.conn.QueryRowContext(ctx, "SELECT pg_catalog.current_setting('application_name')").Scan(&name); err != nil {
+		return
+	}
+	c.config.ApplicationName = name
+}
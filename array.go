@@ -0,0 +1,236 @@
+package postgres
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// parseArray 解析 PostgreSQL 数组字面量（如 {1,2,3}、{{a,b},{c,NULL}}、{"x,y","q\"z"}）
+// 返回嵌套的 []interface{}，元素为 string，NULL 元素为 nil
+func parseArray(s string) ([]interface{}, error) {
+	// 非默认下界的数组带有维度前缀，如 [0:2]={1,2,3}
+	if strings.HasPrefix(s, "[") {
+		if i := strings.Index(s, "="); i >= 0 {
+			s = s[i+1:]
+		}
+	}
+	arr, end, err := parseArrayAt(s, 0)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(s[end:]) != "" {
+		return nil, fmt.Errorf("malformed array literal: %q", s)
+	}
+	return arr, nil
+}
+
+// parseArrayAt 从 s[i]（必须为 '{'）开始解析一层数组，返回数组与结束位置
+func parseArrayAt(s string, i int) ([]interface{}, int, error) {
+	if i >= len(s) || s[i] != '{' {
+		return nil, i, fmt.Errorf("malformed array literal: %q", s)
+	}
+	i++
+
+	arr := []interface{}{}
+	for {
+		i = skipSpaces(s, i)
+		if i >= len(s) {
+			return nil, i, fmt.Errorf("malformed array literal: %q", s)
+		}
+		if s[i] == '}' && len(arr) == 0 {
+			return arr, i + 1, nil
+		}
+
+		switch s[i] {
+		case '{':
+			sub, end, err := parseArrayAt(s, i)
+			if err != nil {
+				return nil, end, err
+			}
+			arr = append(arr, sub)
+			i = end
+		case '"':
+			elem, end, err := parseQuotedElem(s, i)
+			if err != nil {
+				return nil, end, err
+			}
+			arr = append(arr, elem)
+			i = end
+		default:
+			start := i
+			for i < len(s) && s[i] != ',' && s[i] != '}' {
+				i++
+			}
+			elem := strings.TrimSpace(s[start:i])
+			if strings.EqualFold(elem, "NULL") {
+				arr = append(arr, nil)
+			} else {
+				arr = append(arr, elem)
+			}
+		}
+
+		i = skipSpaces(s, i)
+		if i >= len(s) {
+			return nil, i, fmt.Errorf("malformed array literal: %q", s)
+		}
+		switch s[i] {
+		case ',':
+			i++
+		case '}':
+			return arr, i + 1, nil
+		default:
+			return nil, i, fmt.Errorf("malformed array literal: %q", s)
+		}
+	}
+}
+
+// parseQuotedElem 解析以双引号括起的元素，反斜杠转义下一个字符
+func parseQuotedElem(s string, i int) (string, int, error) {
+	var sb strings.Builder
+	for i++; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+			if i < len(s) {
+				sb.WriteByte(s[i])
+			}
+		case '"':
+			return sb.String(), i + 1, nil
+		default:
+			sb.WriteByte(s[i])
+		}
+	}
+	return "", i, fmt.Errorf("unterminated quoted element: %q", s)
+}
+
+// skipSpaces 跳过空白字符
+func skipSpaces(s string, i int) int {
+	for i < len(s) && (s[i] == ' ' || s[i] == '\t' || s[i] == '\n' || s[i] == '\r') {
+		i++
+	}
+	return i
+}
+
+// parseRow 解析复合类型（行）字面量，如 (1,"a b",)，空字段为 NULL
+func parseRow(s string) ([]interface{}, error) {
+	if len(s) < 2 || s[0] != '(' || s[len(s)-1] != ')' {
+		return nil, fmt.Errorf("malformed record literal: %q", s)
+	}
+	body := s[1 : len(s)-1]
+
+	var fields []interface{}
+	var sb strings.Builder
+	quoted, inQuotes := false, false
+	for i := 0; i < len(body); i++ {
+		ch := body[i]
+		switch {
+		case ch == '\\' && i+1 < len(body):
+			i++
+			sb.WriteByte(body[i])
+		case ch == '"' && inQuotes && i+1 < len(body) && body[i+1] == '"':
+			i++
+			sb.WriteByte('"')
+		case ch == '"':
+			inQuotes = !inQuotes
+			quoted = true
+		case ch == ',' && !inQuotes:
+			fields = append(fields, rowField(sb.String(), quoted))
+			sb.Reset()
+			quoted = false
+		default:
+			sb.WriteByte(ch)
+		}
+	}
+	if inQuotes {
+		return nil, fmt.Errorf("malformed record literal: %q", s)
+	}
+	fields = append(fields, rowField(sb.String(), quoted))
+	return fields, nil
+}
+
+// rowField 未加引号的空字段表示 NULL
+func rowField(s string, quoted bool) interface{} {
+	if s == "" && !quoted {
+		return nil
+	}
+	return s
+}
+
+// prettyValue 按列类型美化数组与复合类型的显示，无法解析时保持原样
+//
+//	数组：{1,2,NULL} -> [1, 2, null]，{"a,b",c} -> ["a,b", "c"]
+//	复合：(1,"a b",) -> (1, "a b", NULL)
+func prettyValue(colType *sql.ColumnType, s string) string {
+	typeName := colType.DatabaseTypeName()
+	switch {
+	case strings.HasPrefix(typeName, "_") && (strings.HasPrefix(s, "{") || strings.HasPrefix(s, "[")):
+		arr, err := parseArray(s)
+		if err != nil {
+			return s
+		}
+		return arrayJSON(arr, strings.TrimPrefix(typeName, "_"))
+	case (typeName == "" || typeName == "RECORD") && strings.HasPrefix(s, "(") && strings.HasSuffix(s, ")"):
+		fields, err := parseRow(s)
+		if err != nil {
+			return s
+		}
+		parts := make([]string, len(fields))
+		for i, f := range fields {
+			switch v := f.(type) {
+			case nil:
+				parts[i] = "NULL"
+			case string:
+				if v == "" || strings.ContainsAny(v, " ,()\"") {
+					parts[i] = quoteJSON(v)
+				} else {
+					parts[i] = v
+				}
+			}
+		}
+		return "(" + strings.Join(parts, ", ") + ")"
+	}
+	return s
+}
+
+// arrayJSON 将解析后的数组编码为 JSON 数组；数值与布尔元素不加引号
+func arrayJSON(arr []interface{}, elemType string) string {
+	parts := make([]string, len(arr))
+	for i, elem := range arr {
+		switch v := elem.(type) {
+		case nil:
+			parts[i] = "null"
+		case []interface{}:
+			parts[i] = arrayJSON(v, elemType)
+		case string:
+			switch elemType {
+			case "INT2", "INT4", "INT8", "NUMERIC", "FLOAT4", "FLOAT8", "OID":
+				if json.Valid([]byte(v)) {
+					parts[i] = v
+				} else {
+					parts[i] = quoteJSON(v) // NaN、Infinity
+				}
+			case "BOOL":
+				if v == "t" {
+					parts[i] = "true"
+				} else {
+					parts[i] = "false"
+				}
+			default:
+				parts[i] = quoteJSON(v)
+			}
+		}
+	}
+	return "[" + strings.Join(parts, ", ") + "]"
+}
+
+// quoteJSON 以 JSON 字符串形式加引号（不转义 HTML 字符）
+func quoteJSON(s string) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(s)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
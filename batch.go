@@ -0,0 +1,73 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+)
+
+// RunCommand 非交互地执行一条命令（可为分号分隔的多条语句）后返回，类似 psql -c
+// 未连接时会自动连接（不输出欢迎信息）；结果按当前显示设置输出，遇到第一个错误即停止并返回该错误
+func (c *CLI) RunCommand(sql string) error {
+	if err := c.ensureConnected(); err != nil {
+		return err
+	}
+
+	// 一行中的多条语句由 runSQL 执行，它只在 ON_ERROR_STOP 开启时于失败的语句处停止，因此执行期间临时开启
+	prev, had := c.vars["ON_ERROR_STOP"]
+	c.vars["ON_ERROR_STOP"] = "on"
+	defer func() {
+		if had {
+			c.vars["ON_ERROR_STOP"] = prev
+		} else {
+			delete(c.vars, "ON_ERROR_STOP")
+		}
+	}()
+
+	err := c.runScript(strings.NewReader(sql), "command", nil)
+	if err == errQuit {
+		return nil
+	}
+	return err
+}
+
+// RunFile 非交互地执行整个 SQL 文件后返回，类似 psql -f
+// 设置 ON_ERROR_STOP 时遇到第一个错误即中止并返回该错误，调用方可据此以非零状态退出
+func (c *CLI) RunFile(path string) error {
+	if err := c.ensureConnected(); err != nil {
+		return err
+	}
+
+	err := c.includeFile(path)
+	if err == errQuit {
+		return nil
+	}
+	return err
+}
+
+// Query 在会话连接上执行参数化查询并返回结果集，参数以 $1、$2 占位，由驱动安全传递
+// 未连接时会自动连接；调用方必须在执行下一条语句前关闭返回的 Rows
+// 交互式 REPL 与 RunCommand 仍按原样发送 SQL 文本，不使用参数
+func (c *CLI) Query(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	if err := c.ensureConnected(); err != nil {
+		return nil, err
+	}
+	return c.conn.QueryContext(ctx, query, args...)
+}
+
+// Exec 在会话连接上执行参数化语句（不返回结果集），如 c.Exec(ctx, "DELETE FROM t WHERE id = $1", 42)
+// 未连接时会自动连接；与交互式会话共用连接，因此 SET、事务等会话状态相互可见
+func (c *CLI) Exec(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	if err := c.ensureConnected(); err != nil {
+		return nil, err
+	}
+	return c.conn.ExecContext(ctx, query, args...)
+}
+
+// ensureConnected 未连接时建立连接（不输出欢迎信息）
+func (c *CLI) ensureConnected() error {
+	if c.db != nil {
+		return nil
+	}
+	return c.connect()
+}
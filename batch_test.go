@@ -0,0 +1,35 @@
+package postgres
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRunFileOnErrorStopSetByFile(t *testing.T) {
+	c, _ := newTestCLI(t)
+	path := filepath.Join(t.TempDir(), "migrate.sql")
+	if err := os.WriteFile(path, []byte(failingScript(t, "on")), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.RunFile(path); err == nil {
+		t.Error("RunFile returned nil after a failing statement with ON_ERROR_STOP set by the file")
+	}
+	if _, reached := c.vars["after"]; reached {
+		t.Error("RunFile kept running after the failing statement")
+	}
+}
+
+func TestRunFileContinuesWithoutOnErrorStop(t *testing.T) {
+	c, _ := newTestCLI(t)
+	path := filepath.Join(t.TempDir(), "script.sql")
+	if err := os.WriteFile(path, []byte(failingScript(t, "off")), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.RunFile(path); err != nil {
+		t.Errorf("RunFile error = %v, want nil without ON_ERROR_STOP", err)
+	}
+	if c.vars["after"] != "reached" {
+		t.Error("RunFile stopped although ON_ERROR_STOP is off")
+	}
+}
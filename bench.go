@@ -0,0 +1,129 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// benchOptions \bench 的参数
+type benchOptions struct {
+	runs   int    // 计入统计的执行次数
+	warmup int    // 预热次数，不计入统计
+	query  string // 要执行的 SQL，为空时使用最近一次的 SQL
+}
+
+// parseBenchArgs 解析 \bench N [w=W] [query]
+func parseBenchArgs(args string) (benchOptions, error) {
+	var opts benchOptions
+	fields := strings.Fields(args)
+	if len(fields) == 0 {
+		return opts, fmt.Errorf("\\bench: missing required argument")
+	}
+	n, err := strconv.Atoi(fields[0])
+	if err != nil || n <= 0 {
+		return opts, fmt.Errorf("\\bench: incorrect run count \"%s\"", fields[0])
+	}
+	opts.runs = n
+	rest := strings.TrimSpace(strings.TrimPrefix(args, fields[0]))
+
+	if len(fields) > 1 && (strings.HasPrefix(fields[1], "w=") || strings.HasPrefix(fields[1], "warmup=")) {
+		value := fields[1][strings.IndexByte(fields[1], '=')+1:]
+		w, err := strconv.Atoi(value)
+		if err != nil || w < 0 {
+			return opts, fmt.Errorf("\\bench: incorrect warmup value \"%s\"", value)
+		}
+		opts.warmup = w
+		rest = strings.TrimSpace(strings.TrimPrefix(rest, fields[1]))
+	}
+
+	opts.query = strings.TrimSuffix(rest, ";")
+	return opts, nil
+}
+
+// bench 处理 \bench：重复执行一条 SQL 并丢弃结果，输出耗时统计
+// 按 Ctrl-C 取消正在执行的语句，并输出已完成部分的统计
+func (c *CLI) bench(args string) {
+	opts, err := parseBenchArgs(args)
+	if err != nil {
+		fmt.Fprintf(c.term, "%v\n", err)
+		return
+	}
+	query := opts.query
+	if query == "" {
+		query = strings.TrimSuffix(strings.TrimSpace(c.lastQuery), ";")
+	}
+	if query == "" {
+		fmt.Fprintf(c.term, "\\bench cannot be used with an empty query\n")
+		return
+	}
+	query = c.interpolate(query)
+	if err := c.checkReadOnly(query); err != nil {
+		c.printError(err)
+		return
+	}
+	if !c.confirmDestructive(query) {
+		fmt.Fprintf(c.term, "Statement cancelled.\n")
+		return
+	}
+
+	ctx, stop := interruptContext(context.Background())
+	defer stop()
+
+	var times []time.Duration
+	for i := 0; i < opts.warmup+opts.runs; i++ {
+		start := time.Now()
+		if err := c.benchRun(ctx, query); err != nil {
+			c.trackTransaction(err)
+			if ctx.Err() != nil {
+				fmt.Fprintf(c.term, "Cancel request sent\n")
+				break
+			}
+			c.printError(err)
+			return
+		}
+		if i >= opts.warmup {
+			times = append(times, time.Since(start))
+		}
+	}
+
+	printBenchStats(c, times, opts)
+}
+
+// benchRun 执行一次语句并读取、丢弃全部结果
+func (c *CLI) benchRun(ctx context.Context, query string) error {
+	rows, err := c.conn.QueryContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+	}
+	return rows.Err()
+}
+
+// printBenchStats 输出 \bench 的统计：次数、最小、平均、p95 与最大耗时
+func printBenchStats(c *CLI, times []time.Duration, opts benchOptions) {
+	if len(times) == 0 {
+		fmt.Fprintf(c.term, "No runs completed.\n\n")
+		return
+	}
+
+	var total time.Duration
+	for _, t := range times {
+		total += t
+	}
+	sorted := append([]time.Duration(nil), times...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	// p95 取最近秩：第 ceil(0.95*n) 个值
+	p95 := sorted[int(math.Ceil(0.95*float64(len(sorted))))-1]
+
+	ms := func(d time.Duration) float64 { return d.Seconds() * 1000 }
+	fmt.Fprintf(c.term, "Runs: %d of %d (warmup %d), total %s\n", len(times), opts.runs, opts.warmup, formatDuration(total))
+	fmt.Fprintf(c.term, "min %.3f ms  avg %.3f ms  p95 %.3f ms  max %.3f ms\n\n",
+		ms(sorted[0]), ms(total)/float64(len(times)), ms(p95), ms(sorted[len(sorted)-1]))
+}
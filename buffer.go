@@ -0,0 +1,68 @@
+package postgres
+
+import (
+	"strings"
+)
+
+// queryBuffer 累积多行输入，直到得到一条完整语句
+type queryBuffer struct {
+	lines []string
+}
+
+// add 追加一行输入，返回完整语句以及是否已结束
+// 空行（缓冲区为空时）返回 ("", true)
+func (b *queryBuffer) add(line string) (string, bool) {
+	trimmed := strings.TrimSpace(line)
+
+	// 空行继续等待输入
+	if trimmed == "" && len(b.lines) == 0 {
+		return "", true
+	}
+
+	// 如果是第一行，检查是否是特殊命令（不需要分号）
+	if len(b.lines) == 0 {
+		// 如果是 psql 命令（以反斜杠开头），直接返回
+		if strings.HasPrefix(trimmed, "\\") {
+			return trimmed, true
+		}
+		// 检查其他特殊命令（exit, quit, help）
+		cmdLower := strings.ToLower(trimmed)
+		if cmdLower == "exit" || cmdLower == "quit" || cmdLower == "help" {
+			return trimmed, true
+		}
+	}
+
+	b.lines = append(b.lines, line)
+
+	// 以顶层分号结束，且引号、注释与括号均已闭合时语句完整
+	open, depth, terminated := inputStatus(strings.Join(b.lines, "\n"))
+	if terminated && open == "" && depth <= 0 {
+		return b.flush(), true
+	}
+	return "", false
+}
+
+// state 返回续行状态，用于提示符 %R：
+// 未闭合的引号为 ' 或 "，dollar 引用为 $，块注释为 *，括号为 (，否则为 -
+func (b *queryBuffer) state() string {
+	open, depth, _ := inputStatus(strings.Join(b.lines, "\n"))
+	switch {
+	case open != "":
+		return open
+	case depth > 0:
+		return "("
+	}
+	return "-"
+}
+
+// empty 缓冲区是否为空
+func (b *queryBuffer) empty() bool {
+	return len(b.lines) == 0
+}
+
+// flush 返回缓冲区内容并清空
+func (b *queryBuffer) flush() string {
+	result := strings.Join(b.lines, "\n")
+	b.lines = nil
+	return result
+}
@@ -0,0 +1,66 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// callTransactionHint 过程中的 COMMIT/ROLLBACK 失败（invalid_transaction_termination）时的说明
+const callTransactionHint = "procedures that commit or roll back must be called outside a transaction block: end it with COMMIT or ROLLBACK first, send the CALL on its own rather than together with other statements, and do not use single-transaction mode"
+
+// isCall 判断语句是否为 CALL（PostgreSQL 11 起的存储过程调用）
+func isCall(sqlStr string) bool {
+	words := topLevelWords(stripLeadingComments(sqlStr))
+	return len(words) > 0 && words[0] == "CALL"
+}
+
+// executeCall 执行 CALL：有 INOUT/OUT 参数的过程返回一行参数值，与 psql 一致按当前输出格式显示；
+// 没有返回列时输出命令类型 CALL
+// CLI 不为过程中的事务控制做特殊处理：与其他语句同一行发送时服务器将它们作为一个隐式事务执行，
+// 过程中的 COMMIT/ROLLBACK 会失败（2D000），此时由 printCallHint 说明
+func (c *CLI) executeCall(ctx context.Context, sqlStr string, startTime time.Time) error {
+	rows, closeRows, err := c.queryRows(ctx, sqlStr)
+	if err != nil {
+		c.printError(err)
+		c.printCallHint(err)
+		return err
+	}
+	defer closeRows()
+	execTime := time.Since(startTime)
+
+	defer c.startPager()()
+	defer c.captureResult()()
+
+	cols, _ := rows.Columns()
+	if len(cols) == 0 {
+		if err := rows.Err(); err != nil {
+			c.printError(err)
+			return err
+		}
+		c.lastRowCount, c.lastTag = 0, "CALL"
+		fmt.Fprintf(c.out, "CALL\n")
+		c.printTiming(time.Since(startTime))
+		fmt.Fprintf(c.out, "\n")
+		return nil
+	}
+
+	n, err := c.displayResultSet(rows, sqlStr)
+	if err == nil {
+		err = rows.Err()
+	}
+	if err != nil {
+		c.printError(err)
+		c.printCallHint(err)
+		return err
+	}
+	c.lastRowCount, c.lastTag = int64(n), "CALL"
+	c.printQueryTiming(time.Since(startTime), execTime)
+	fmt.Fprintf(c.out, "\n")
+	return nil
+}
+
+// printCallHint 过程因处于事务块中无法提交或回滚（2D000）时给出提示
+func (c *CLI) printCallHint(err error) {
+	c.printHint(err, callTransactionHint, "2D000")
+}
@@ -0,0 +1,325 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// systemSchemaFilter 未指定模式时排除系统 schema 的过滤条件
+const systemSchemaFilter = "n.nspname NOT IN ('pg_catalog', 'information_schema')"
+
+// patternToRegex 将 psql 风格的名称模式转换为正则表达式
+// * 匹配任意字符串，? 匹配单个字符；未加双引号的部分转为小写
+func patternToRegex(pattern string) string {
+	var sb strings.Builder
+	inQuote := false
+	for _, r := range pattern {
+		switch {
+		case r == '"':
+			inQuote = !inQuote
+		case r == '*' && !inQuote:
+			sb.WriteString(".*")
+		case r == '?' && !inQuote:
+			sb.WriteString(".")
+		case inQuote:
+			sb.WriteString(regexp.QuoteMeta(string(r)))
+		default:
+			sb.WriteString(regexp.QuoteMeta(strings.ToLower(string(r))))
+		}
+	}
+	return "^(" + sb.String() + ")$"
+}
+
+// splitPattern 拆分 schema.name 形式的模式（忽略双引号内的点号）
+func splitPattern(pattern string) (schema, name string) {
+	inQuote := false
+	for i, r := range pattern {
+		switch {
+		case r == '"':
+			inQuote = !inQuote
+		case r == '.' && !inQuote:
+			return pattern[:i], pattern[i+1:]
+		}
+	}
+	return "", pattern
+}
+
+// patternCondition 根据名称模式生成过滤条件；模式为空时排除系统 schema
+func patternCondition(pattern, schemaCol, nameCol string) string {
+	if pattern == "" {
+		return systemSchemaFilter
+	}
+
+	schema, name := splitPattern(pattern)
+	var conds []string
+	if name != "" && name != "*" {
+		conds = append(conds, fmt.Sprintf("%s ~ %s", nameCol, pq.QuoteLiteral(patternToRegex(name))))
+	}
+	if schema != "" && schema != "*" {
+		conds = append(conds, fmt.Sprintf("%s ~ %s", schemaCol, pq.QuoteLiteral(patternToRegex(schema))))
+	}
+	if len(conds) == 0 {
+		return "true"
+	}
+	return strings.Join(conds, " AND ")
+}
+
+// visibleCondition 与 psql 一致：模式未指定 schema 时只匹配 search_path 中可见的对象（visible 为可见性表达式），
+// 并排除系统 schema；指定了 schema（如 *.*、public.t*）时按 schema 匹配
+func visibleCondition(pattern, schemaCol, nameCol, visible string) string {
+	if schema, _ := splitPattern(pattern); schema != "" {
+		return patternCondition(pattern, schemaCol, nameCol)
+	}
+	cond := systemSchemaFilter + " AND " + visible
+	if pattern != "" && pattern != "*" {
+		cond += " AND " + patternCondition(pattern, schemaCol, nameCol)
+	}
+	return cond
+}
+
+// commandPattern 返回元命令的名称模式参数（第二个参数），没有时返回空串
+func commandPattern(cmd string) string {
+	parts := strings.Fields(cmd)
+	if len(parts) >= 2 {
+		return parts[1]
+	}
+	return ""
+}
+
+// listAggregates 列出聚合函数（\da）
+func (c *CLI) listAggregates(pattern string) {
+	c.executeSQL(fmt.Sprintf("SELECT n.nspname AS \"Schema\", p.proname AS \"Name\", pg_catalog.format_type(p.prorettype, NULL) AS \"Result data type\", CASE WHEN p.pronargs = 0 THEN '*' ELSE pg_catalog.pg_get_function_arguments(p.oid) END AS \"Argument data types\", pg_catalog.obj_description(p.oid, 'pg_proc') AS \"Description\" FROM pg_catalog.pg_proc p LEFT JOIN pg_catalog.pg_namespace n ON n.oid = p.pronamespace WHERE EXISTS (SELECT 1 FROM pg_catalog.pg_aggregate a WHERE a.aggfnoid = p.oid) AND %s ORDER BY 1, 2, 4",
+		patternCondition(pattern, "n.nspname", "p.proname")))
+}
+
+// listOperators 列出运算符（\do）
+func (c *CLI) listOperators(pattern string) {
+	c.executeSQL(fmt.Sprintf("SELECT n.nspname AS \"Schema\", o.oprname AS \"Name\", CASE WHEN o.oprkind = 'l' THEN NULL ELSE pg_catalog.format_type(o.oprleft, NULL) END AS \"Left arg type\", CASE WHEN o.oprkind = 'r' THEN NULL ELSE pg_catalog.format_type(o.oprright, NULL) END AS \"Right arg type\", pg_catalog.format_type(o.oprresult, NULL) AS \"Result type\", pg_catalog.obj_description(o.oid, 'pg_operator') AS \"Description\" FROM pg_catalog.pg_operator o LEFT JOIN pg_catalog.pg_namespace n ON n.oid = o.oprnamespace WHERE %s ORDER BY 1, 2, 3, 4",
+		patternCondition(pattern, "n.nspname", "o.oprname")))
+}
+
+// listConversions 列出编码转换（\dc）
+func (c *CLI) listConversions(pattern string) {
+	c.executeSQL(fmt.Sprintf("SELECT n.nspname AS \"Schema\", c.conname AS \"Name\", pg_catalog.pg_encoding_to_char(c.conforencoding) AS \"Source\", pg_catalog.pg_encoding_to_char(c.contoencoding) AS \"Destination\", CASE WHEN c.condefault THEN 'yes' ELSE 'no' END AS \"Default?\" FROM pg_catalog.pg_conversion c JOIN pg_catalog.pg_namespace n ON n.oid = c.connamespace WHERE %s ORDER BY 1, 2",
+		patternCondition(pattern, "n.nspname", "c.conname")))
+}
+
+// listOptions \dt、\di 的参数：名称模式与排序方式
+type listOptions struct {
+	pattern string
+	sortBy  string // name（默认）或 size
+	desc    bool   // 降序；--sort=size 默认降序
+}
+
+// parseListArgs 解析 [PATTERN] [--sort=name|size] [--asc|--desc]，参数可以任意顺序出现
+func parseListArgs(cmd string) (listOptions, error) {
+	opts := listOptions{sortBy: "name"}
+	order := ""
+	parts := strings.Fields(cmd)
+	for _, arg := range parts[1:] {
+		switch {
+		case strings.HasPrefix(arg, "--sort="):
+			opts.sortBy = strings.ToLower(strings.TrimPrefix(arg, "--sort="))
+			if opts.sortBy != "name" && opts.sortBy != "size" {
+				return opts, fmt.Errorf("%s: --sort must be name or size", parts[0])
+			}
+		case arg == "--asc" || arg == "--desc":
+			order = arg
+		case strings.HasPrefix(arg, "--"):
+			return opts, fmt.Errorf("%s: unrecognized option \"%s\"", parts[0], arg)
+		case opts.pattern == "":
+			opts.pattern = arg
+		default:
+			return opts, fmt.Errorf("%s: too many arguments", parts[0])
+		}
+	}
+	opts.desc = order == "--desc" || order == "" && opts.sortBy == "size"
+	return opts, nil
+}
+
+// orderBy 返回列表的 ORDER BY 子句，前两列为 schema 与名称，sizeExpr 为按大小排序时使用的表达式
+func (o listOptions) orderBy(sizeExpr string) string {
+	dir := ""
+	if o.desc {
+		dir = " DESC"
+	}
+	if o.sortBy == "size" {
+		return fmt.Sprintf("ORDER BY %s%s, 1, 2", sizeExpr, dir)
+	}
+	return fmt.Sprintf("ORDER BY 1%s, 2%s", dir, dir)
+}
+
+// listTables 列出表（\dt）；默认只列出 search_path 中可见的表，\dt *.* 列出所有 schema 的表
+// verbose（\dt+）时增加大小与描述
+func (c *CLI) listTables(opts listOptions, verbose bool) {
+	sizeExpr := "pg_catalog.pg_table_size(c.oid)"
+	extra := ""
+	if verbose {
+		extra = fmt.Sprintf(", pg_catalog.pg_size_pretty(%s) AS \"Size\", pg_catalog.obj_description(c.oid, 'pg_class') AS \"Description\"", sizeExpr)
+	}
+	c.executeSQL(fmt.Sprintf("SELECT n.nspname AS \"Schema\", c.relname AS \"Name\", pg_catalog.pg_get_userbyid(c.relowner) AS \"Owner\"%s FROM pg_catalog.pg_class c LEFT JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace WHERE c.relkind IN ('r', 'p') AND n.nspname !~ '^pg_toast' AND %s %s",
+		extra, visibleCondition(opts.pattern, "n.nspname", "c.relname", "pg_catalog.pg_table_is_visible(c.oid)"), opts.orderBy(sizeExpr)))
+}
+
+// listIndexes 列出索引（\di），可见性规则与 \dt 相同
+// 显示是否为主键或唯一索引，以及索引的列、INCLUDE 列与部分索引条件（pg_get_indexdef 中 USING 之后的部分）
+// verbose（\di+）时增加索引方法、大小与描述
+func (c *CLI) listIndexes(opts listOptions, verbose bool) {
+	sizeExpr := "pg_catalog.pg_relation_size(c.oid)"
+	extra := ""
+	if verbose {
+		extra = fmt.Sprintf(", am.amname AS \"Method\", pg_catalog.pg_size_pretty(%s) AS \"Size\", pg_catalog.obj_description(c.oid, 'pg_class') AS \"Description\"", sizeExpr)
+	}
+	c.executeSQL(fmt.Sprintf("SELECT n.nspname AS \"Schema\", c.relname AS \"Name\", t.relname AS \"Table\", pg_catalog.pg_get_userbyid(c.relowner) AS \"Owner\", CASE WHEN i.indisprimary THEN 'primary key' WHEN i.indisunique THEN 'unique' ELSE '' END AS \"Unique\", pg_catalog.regexp_replace(pg_catalog.pg_get_indexdef(c.oid), '^.*? USING \\w+ ', '') AS \"Definition\"%s FROM pg_catalog.pg_class c LEFT JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace JOIN pg_catalog.pg_index i ON i.indexrelid = c.oid JOIN pg_catalog.pg_class t ON t.oid = i.indrelid LEFT JOIN pg_catalog.pg_am am ON am.oid = c.relam WHERE c.relkind IN ('i', 'I') AND n.nspname !~ '^pg_toast' AND %s %s",
+		extra, visibleCondition(opts.pattern, "n.nspname", "c.relname", "pg_catalog.pg_table_is_visible(c.oid)"), opts.orderBy(sizeExpr)))
+}
+
+// listDescriptions 列出没有专门显示命令的对象的注释（\dd）：表与域的约束、运算符类与族、规则、触发器
+func (c *CLI) listDescriptions(pattern string) {
+	branches := []struct{ object, alias, name, from string }{
+		{"table constraint", "pgc", "pgc.conname", "pg_catalog.pg_constraint pgc JOIN pg_catalog.pg_class c ON c.oid = pgc.conrelid LEFT JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace"},
+		{"domain constraint", "pgc", "pgc.conname", "pg_catalog.pg_constraint pgc JOIN pg_catalog.pg_type t ON t.oid = pgc.contypid LEFT JOIN pg_catalog.pg_namespace n ON n.oid = t.typnamespace"},
+		{"operator class", "o", "o.opcname", "pg_catalog.pg_opclass o JOIN pg_catalog.pg_namespace n ON n.oid = o.opcnamespace"},
+		{"operator family", "o", "o.opfname", "pg_catalog.pg_opfamily o JOIN pg_catalog.pg_namespace n ON n.oid = o.opfnamespace"},
+		{"rule", "r", "r.rulename", "pg_catalog.pg_rewrite r JOIN pg_catalog.pg_class c ON c.oid = r.ev_class LEFT JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace"},
+		{"trigger", "t", "t.tgname", "pg_catalog.pg_trigger t JOIN pg_catalog.pg_class c ON c.oid = t.tgrelid LEFT JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace"},
+	}
+
+	var parts []string
+	for _, b := range branches {
+		cond := patternCondition(pattern, "n.nspname", b.name)
+		if b.object == "rule" {
+			// 视图的 _RETURN 规则不是用户定义的规则
+			cond += " AND r.rulename <> '_RETURN'"
+		}
+		parts = append(parts, fmt.Sprintf("SELECT %[1]s.oid, %[1]s.tableoid, n.nspname, %[2]s::pg_catalog.text AS name, '%[3]s'::pg_catalog.text AS object FROM %[4]s WHERE %[5]s",
+			b.alias, b.name, b.object, b.from, cond))
+	}
+	c.executeSQL(fmt.Sprintf("SELECT DISTINCT tt.nspname AS \"Schema\", tt.name AS \"Name\", tt.object AS \"Object\", d.description AS \"Description\" FROM (%s) AS tt JOIN pg_catalog.pg_description d ON tt.oid = d.objoid AND tt.tableoid = d.classoid AND d.objsubid = 0 ORDER BY 1, 2, 3",
+		strings.Join(parts, " UNION ALL ")))
+}
+
+// listTableSizes 列出表与物化视图的空间占用明细（\d++）：表本身、索引、TOAST、合计以及估算行数
+// 按合计大小降序排列，reltuples 为 -1（从未 ANALYZE）时行数显示为 NULL
+func (c *CLI) listTableSizes(pattern string) {
+	c.executeSQL(fmt.Sprintf("SELECT n.nspname AS \"Schema\", c.relname AS \"Name\", CASE WHEN c.reltuples < 0 THEN NULL ELSE c.reltuples::bigint END AS \"Rows (estimated)\", pg_catalog.pg_size_pretty(pg_catalog.pg_relation_size(c.oid)) AS \"Table\", pg_catalog.pg_size_pretty(pg_catalog.pg_indexes_size(c.oid)) AS \"Indexes\", pg_catalog.pg_size_pretty(CASE WHEN c.reltoastrelid = 0 THEN 0 ELSE pg_catalog.pg_total_relation_size(c.reltoastrelid) END) AS \"TOAST\", pg_catalog.pg_size_pretty(pg_catalog.pg_total_relation_size(c.oid)) AS \"Total\" FROM pg_catalog.pg_class c LEFT JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace WHERE c.relkind IN ('r', 'm') AND %s ORDER BY pg_catalog.pg_total_relation_size(c.oid) DESC, 1, 2",
+		patternCondition(pattern, "n.nspname", "c.relname")))
+}
+
+// nameCondition 根据名称模式生成不属于任何 schema 的对象的过滤条件；模式为空时不过滤
+func nameCondition(pattern, nameCol string) string {
+	if pattern == "" || pattern == "*" {
+		return "true"
+	}
+	return fmt.Sprintf("%s ~ %s", nameCol, pq.QuoteLiteral(patternToRegex(pattern)))
+}
+
+// listForeign 列出外部数据包装器、外部服务器、用户映射或外部表（\dew、\des、\deu、\det）
+// verbose 为真时（带 +）追加 FDW 选项等列
+func (c *CLI) listForeign(command string, verbose bool, pattern string) {
+	var query string
+	switch command {
+	case "\\dew":
+		cols := "fdw.fdwname AS \"Name\", pg_catalog.pg_get_userbyid(fdw.fdwowner) AS \"Owner\", fdw.fdwhandler::pg_catalog.regproc AS \"Handler\", fdw.fdwvalidator::pg_catalog.regproc AS \"Validator\""
+		if verbose {
+			cols += ", pg_catalog.array_to_string(fdw.fdwacl, ', ') AS \"Access privileges\", pg_catalog.array_to_string(fdw.fdwoptions, ', ') AS \"FDW options\", pg_catalog.obj_description(fdw.oid, 'pg_foreign_data_wrapper') AS \"Description\""
+		}
+		query = fmt.Sprintf("SELECT %s FROM pg_catalog.pg_foreign_data_wrapper fdw WHERE %s ORDER BY 1",
+			cols, nameCondition(pattern, "fdw.fdwname"))
+	case "\\des":
+		cols := "s.srvname AS \"Name\", pg_catalog.pg_get_userbyid(s.srvowner) AS \"Owner\", f.fdwname AS \"Foreign-data wrapper\""
+		if verbose {
+			cols += ", pg_catalog.array_to_string(s.srvacl, ', ') AS \"Access privileges\", s.srvtype AS \"Type\", s.srvversion AS \"Version\", pg_catalog.array_to_string(s.srvoptions, ', ') AS \"FDW options\", pg_catalog.obj_description(s.oid, 'pg_foreign_server') AS \"Description\""
+		}
+		query = fmt.Sprintf("SELECT %s FROM pg_catalog.pg_foreign_server s JOIN pg_catalog.pg_foreign_data_wrapper f ON f.oid = s.srvfdw WHERE %s ORDER BY 1",
+			cols, nameCondition(pattern, "s.srvname"))
+	case "\\deu":
+		cols := "um.srvname AS \"Server\", um.usename AS \"User name\""
+		if verbose {
+			cols += ", pg_catalog.array_to_string(um.umoptions, ', ') AS \"FDW options\""
+		}
+		query = fmt.Sprintf("SELECT %s FROM pg_catalog.pg_user_mappings um WHERE %s ORDER BY 1, 2",
+			cols, nameCondition(pattern, "um.usename"))
+	case "\\det":
+		cols := "n.nspname AS \"Schema\", c.relname AS \"Table\", s.srvname AS \"Server\""
+		if verbose {
+			cols += ", pg_catalog.array_to_string(ft.ftoptions, ', ') AS \"FDW options\", pg_catalog.obj_description(c.oid, 'pg_class') AS \"Description\""
+		}
+		query = fmt.Sprintf("SELECT %s FROM pg_catalog.pg_foreign_table ft JOIN pg_catalog.pg_class c ON c.oid = ft.ftrelid JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace JOIN pg_catalog.pg_foreign_server s ON s.oid = ft.ftserver WHERE %s ORDER BY 1, 2",
+			cols, patternCondition(pattern, "n.nspname", "c.relname"))
+	}
+	c.executeSQL(query)
+}
+
+// textSearchCatalogs \dF 系列命令对应的全文检索系统表：表名、名称列、schema 列
+var textSearchCatalogs = map[string][3]string{
+	"\\dF":  {"pg_ts_config", "cfgname", "cfgnamespace"},
+	"\\dFd": {"pg_ts_dict", "dictname", "dictnamespace"},
+	"\\dFp": {"pg_ts_parser", "prsname", "prsnamespace"},
+	"\\dFt": {"pg_ts_template", "tmplname", "tmplnamespace"},
+}
+
+// listTextSearch 列出全文检索配置、词典、解析器或模板（\dF、\dFd、\dFp、\dFt）
+// 内置对象位于 pg_catalog，因此未指定模式时不排除系统 schema
+func (c *CLI) listTextSearch(command, pattern string) {
+	catalog := textSearchCatalogs[command]
+	table, nameCol, nsCol := catalog[0], catalog[1], catalog[2]
+
+	cond := "true"
+	if pattern != "" {
+		cond = patternCondition(pattern, "n.nspname", "o."+nameCol)
+	}
+	c.executeSQL(fmt.Sprintf("SELECT n.nspname AS \"Schema\", o.%s AS \"Name\", pg_catalog.obj_description(o.oid, '%s') AS \"Description\" FROM pg_catalog.%s o LEFT JOIN pg_catalog.pg_namespace n ON n.oid = o.%s WHERE %s ORDER BY 1, 2",
+		nameCol, table, table, nsCol, cond))
+}
+
+// showFunctionSource 显示函数定义（\sf），numbered 为真时为函数体加行号（\sf+）
+// 行号从 AS 所在行开始计为 1，与服务器错误中 PL/pgSQL 的 line N 对应
+func (c *CLI) showFunctionSource(function string, numbered bool) {
+	if function == "" {
+		fmt.Fprintf(c.term, "\\sf: function name is required\n")
+		return
+	}
+
+	// 带参数列表时按完整签名定位，否则按名称定位（重名函数会报错）
+	cast := "regproc"
+	if strings.Contains(function, "(") {
+		cast = "regprocedure"
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var def string
+	query := fmt.Sprintf("SELECT pg_catalog.pg_get_functiondef(%s::pg_catalog.%s::pg_catalog.oid)", pq.QuoteLiteral(function), cast)
+	if err := c.conn.QueryRowContext(ctx, query).Scan(&def); err != nil {
+		c.printError(err)
+		return
+	}
+
+	lines := strings.Split(strings.TrimRight(def, "\n"), "\n")
+	if !numbered {
+		for _, line := range lines {
+			fmt.Fprintf(c.term, "%s\n", line)
+		}
+		return
+	}
+
+	lineNo := 0
+	for _, line := range lines {
+		if lineNo == 0 && strings.HasPrefix(line, "AS ") {
+			lineNo = 1
+		}
+		if lineNo == 0 {
+			fmt.Fprintf(c.term, "        %s\n", line)
+			continue
+		}
+		fmt.Fprintf(c.term, "%-7d %s\n", lineNo, line)
+		lineNo++
+	}
+}
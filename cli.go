@@ -1,15 +1,19 @@
 package postgres
 
 import (
+	"bufio"
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"io"
+	"math"
+	"os"
 	"strconv"
 	"strings"
 	"time"
 
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
 // Terminal 终端接口，用于输入输出
@@ -35,20 +39,66 @@ type Config struct {
 	SearchPath      string        // 搜索路径
 	TimeZone        string        // 时区
 	CustomParams    string        // 自定义参数，如 "param1=value1&param2=value2"
+	SingleTransaction bool        // RunFile/\i 将整个文件包裹在单个事务中执行（psql -1）
+	TCPKeepAlive    bool          // 启用 TCP keepalive（使用系统默认参数）
+	KeepalivesIdle  time.Duration // 空闲多久后开始发送 keepalive 探测，设置即启用 keepalive
+	KeepalivesInterval time.Duration // keepalive 探测间隔（仅 Linux）
+	KeepalivesCount int           // 判定连接断开前的探测次数（仅 Linux）
+	IdleTimeout     time.Duration // 交互式会话无输入超过该时长后 Start 返回，默认 0（不限制）
+	ReadOnly        bool          // 只读模式：会话默认只读事务，并在客户端拒绝写操作
+	ConfirmDestructive bool       // 交互模式下执行 DROP、TRUNCATE、无 WHERE 的 DELETE/UPDATE 等语句前要求确认
+	WarnExpensive   bool          // 交互模式下先 EXPLAIN 查询，估算代价或行数超过阈值时要求确认
+	WarnCost        float64       // WarnExpensive 的代价阈值，默认 1e7
+	WarnRows        int64         // WarnExpensive 的行数阈值，默认 1000000
+	Color           string        // 颜色输出：auto（默认，TTY 且未设置 NO_COLOR 时启用）/always/never
+	Highlight       bool          // 输入时高亮 SQL 关键字与字符串（需启用颜色）
+	LogFile         string        // 查询日志文件，追加记录每条执行的语句、耗时与行数或错误
+	TranscriptFile  string        // 会话记录文件，追加记录提示符、输入与全部输出（同 \script -a）
+	Hosts           []string      // 多个候选主机（"host" 或 "host:port"），按顺序尝试；为空时使用 Host（可逗号分隔）
+	TargetSessionAttrs string      // any/read-write/read-only/primary/standby，默认 any
+	AllowShell      bool          // 允许在本机运行程序（如 \clip 调用的剪贴板程序），默认禁止
 }
 
 // CLI PostgreSQL 交互式命令行客户端
 type CLI struct {
 	term          Terminal
+	out           io.Writer // 查询结果的输出，默认为 term，\o 时为文件或同时输出到终端与文件
+	outFile       *os.File  // \o 打开的文件
+	transcript    *transcriptTerminal // \script 或 Config.TranscriptFile 的会话记录
 	config        *Config
 	db            *sql.DB
+	conn          *sql.Conn // 固定的会话连接，保证事务与 SET 等会话状态在同一连接上生效
 	reader        *Reader
 	serverInfo    ServerInfo
-	expandedMode  bool // \x 扩展显示模式
-	timingEnabled bool // \timing 计时
-	maxRows       int  // 最大显示行数
+	settings      Settings // 输出格式与行为选项（\pset、\x、\timing 等）
 	inTransaction bool // 是否在事务中
+	singleTxn     bool // 正在以单事务模式执行文件
+	singleTxnFailed bool // 单事务模式下是否有语句失败
+	userSQL       bool // 正在执行用户输入的 SQL（runSQL），而非元命令生成的查询
+	scriptInput   *bufio.Scanner // 正在执行的脚本（\i、RunFile、RunCommand）的输入，\copy ... from stdin 从中读取数据
+	txnFailed     bool // 事务因错误进入失败状态，只能回滚
+	savepoints    []string // 当前事务中的保存点，外层在前（\sp）
 	database      string
+	host          string // 当前连接的主机
+	port          int    // 当前连接的端口
+	superuser     bool   // 当前用户是否为超级用户（提示符 %#）
+	color         bool   // 是否输出 ANSI 颜色（Config.Color）
+	vars          map[string]string // \set 设置的变量
+	lastErr       error             // 最近一次错误，供 \errverbose 使用
+	history       []string          // 本次会话输入的命令，供 \s 使用
+	logger        *queryLogger      // Config.LogFile 查询日志
+	lastRowCount  int64             // 最近一条语句返回或影响的行数，-1 表示未知
+	lastTag       string            // 最近一条语句的命令标签（不含行数），未成功执行时为空
+	onStatement   func(sql string, tag string, rows int64, d time.Duration, err error) // OnStatement 注册的回调
+	lastQuery     string            // 最近执行的 SQL 输入，供 \watch 重复执行
+	lastResult    resultCapture     // 最近一次查询结果的输出，供 \clip 复制
+	watchDiff     bool              // \watch -d：高亮与上一次结果不同的单元格
+	watching      bool              // 正在执行 \watch，结果不分页
+	bindParams    []interface{}     // \bind 设置、供下一条 SQL 使用的参数
+	stmtParams    []interface{}     // 当前执行语句的参数（$1、$2 …）
+	listener      *pq.Listener      // LISTEN 的通知连接，首次 LISTEN 时建立
+	watchPrev     [][]string        // \watch 上一次的结果
+	watchCurr     [][]string        // \watch 本次的结果
 }
 
 // ServerInfo PostgreSQL 服务器信息
@@ -57,6 +107,9 @@ type ServerInfo struct {
 	ServerEncoding string
 	ClientEncoding string
 	ConnectionID  int
+	VersionNum    int // server_version_num，如 160002
+	Major         int // 主版本号，如 16；9.x 及更早为 9
+	Minor         int // 次版本号，如 2；9.x 及更早为 9.6 中的 6
 }
 
 // NewCLI 创建新的 PostgreSQL CLI 实例（兼容旧接口）
@@ -98,39 +151,177 @@ func NewCLIWithConfig(term Terminal, config *Config) *CLI {
 	if config.ApplicationName == "" {
 		config.ApplicationName = "psql"
 	}
+	if config.WarnCost == 0 {
+		config.WarnCost = defaultWarnCost
+	}
+	if config.WarnRows == 0 {
+		config.WarnRows = defaultWarnRows
+	}
 
+	reader := NewReader(term)
+	color := useColor(config.Color, reader.Interactive())
+	if config.Highlight && color {
+		reader.EnableHighlight()
+	}
 	return &CLI{
 		term:     term,
+		out:      term,
 		config:   config,
 		database: config.Database,
-		reader:   NewReader(term),
-		maxRows:  1000,
-		timingEnabled: false,
+		reader:   reader,
+		color:    color,
+		settings: DefaultSettings(),
+		vars:     make(map[string]string),
 	}
 }
 
+// maxPasswordAttempts 交互式输入密码的最大尝试次数
+const maxPasswordAttempts = 3
+
 // Connect 连接到 PostgreSQL 数据库
+// 未配置密码且输入为 TTY 时提示输入密码（掩码），认证失败时重新提示
 func (c *CLI) Connect() error {
-	// 构建 DSN
+	if c.config.TranscriptFile != "" && c.transcript == nil {
+		if err := c.startTranscript(c.config.TranscriptFile, true); err != nil {
+			return err
+		}
+	}
+
+	for attempt := 1; ; attempt++ {
+		if c.config.Password == "" && c.reader.Interactive() {
+			password, err := c.reader.ReadPassword(fmt.Sprintf("Password for user %s: ", c.config.Username))
+			if err != nil {
+				return err
+			}
+			c.config.Password = password
+		}
+
+		err := c.connect()
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, ErrAuthFailed) || !c.reader.Interactive() || attempt >= maxPasswordAttempts {
+			return err
+		}
+		fmt.Fprintf(c.term, "psql: %v\n", err)
+		c.config.Password = ""
+	}
+
+	// 显示欢迎信息（非交互输入时不显示）
+	if c.reader.Interactive() {
+		c.showWelcome()
+	}
+
+	return nil
+}
+
+// connect 建立连接并获取服务器信息，不输出欢迎信息
+func (c *CLI) connect() error {
+	if c.config.LogFile != "" && c.logger == nil {
+		logger, err := newQueryLogger(c.config.LogFile)
+		if err != nil {
+			return err
+		}
+		c.logger = logger
+	}
+
+	db, conn, hp, err := c.dial(c.config.Database)
+	if err != nil {
+		return err
+	}
+	c.db, c.conn = db, conn
+	c.host, c.port = hp.host, hp.port
+
+	// 获取服务器信息
+	c.fetchServerInfo()
+
+	return nil
+}
+
+// dial 依次尝试候选主机，返回第一个满足 TargetSessionAttrs 的连接
+func (c *CLI) dial(dbName string) (*sql.DB, *sql.Conn, hostPort, error) {
+	if !validTargetSessionAttrs[c.config.TargetSessionAttrs] {
+		return nil, nil, hostPort{}, fmt.Errorf("invalid target_session_attrs value: \"%s\"", c.config.TargetSessionAttrs)
+	}
+
+	hosts := c.candidateHosts()
+	var lastErr error
+	for _, hp := range hosts {
+		db, conn, err := c.dialHost(hp, dbName)
+		if err == nil {
+			return db, conn, hp, nil
+		}
+		lastErr = err
+		if len(hosts) > 1 {
+			lastErr = fmt.Errorf("connection to server at \"%s\", port %d failed: %w", hp.host, hp.port, err)
+		}
+	}
+	// 连接错误可能引用连接串，返回前去除其中的密码
+	return nil, nil, hostPort{}, c.classifyConnectError(lastErr)
+}
+
+// dialHost 连接单个主机并检查会话属性
+func (c *CLI) dialHost(hp hostPort, dbName string) (*sql.DB, *sql.Conn, error) {
+	db, err := c.openDB(c.buildDSN(hp, dbName))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// 设置连接池参数
+	db.SetMaxOpenConns(c.config.MaxOpenConns)
+	db.SetMaxIdleConns(c.config.MaxIdleConns)
+	db.SetConnMaxLifetime(c.config.ConnMaxLifetime)
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, nil, err
+	}
+
+	conn, err := db.Conn(context.Background())
+	if err != nil {
+		db.Close()
+		return nil, nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.config.ConnectTimeout)
+	defer cancel()
+	if err := checkSessionAttrs(ctx, conn, c.config.TargetSessionAttrs); err != nil {
+		conn.Close()
+		db.Close()
+		return nil, nil, err
+	}
+	if c.config.ReadOnly {
+		if err := setReadOnly(ctx, conn); err != nil {
+			conn.Close()
+			db.Close()
+			return nil, nil, err
+		}
+	}
+
+	return db, conn, nil
+}
+
+// buildDSN 构建指定主机与数据库的 DSN
+func (c *CLI) buildDSN(hp hostPort, dbName string) string {
 	dsn := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s connect_timeout=%d",
-		c.config.Host,
-		c.config.Port,
-		c.config.Username,
-		c.config.Password,
-		c.config.Database,
+		quoteDSNValue(hp.host),
+		hp.port,
+		quoteDSNValue(c.config.Username),
+		quoteDSNValue(c.config.Password),
+		quoteDSNValue(dbName),
 		c.config.SSLMode,
 		int(c.config.ConnectTimeout.Seconds()),
 	)
 
 	// 添加可选参数
 	if c.config.ApplicationName != "" {
-		dsn += fmt.Sprintf(" application_name=%s", c.config.ApplicationName)
+		dsn += fmt.Sprintf(" application_name=%s", quoteDSNValue(c.config.ApplicationName))
 	}
 	if c.config.SearchPath != "" {
-		dsn += fmt.Sprintf(" search_path=%s", c.config.SearchPath)
+		dsn += fmt.Sprintf(" search_path=%s", quoteDSNValue(c.config.SearchPath))
 	}
 	if c.config.TimeZone != "" {
-		dsn += fmt.Sprintf(" timezone=%s", c.config.TimeZone)
+		dsn += fmt.Sprintf(" timezone=%s", quoteDSNValue(c.config.TimeZone))
 	}
 	if c.config.StatementTimeout > 0 {
 		dsn += fmt.Sprintf(" statement_timeout=%d", int(c.config.StatementTimeout.Milliseconds()))
@@ -138,47 +329,50 @@ func (c *CLI) Connect() error {
 	if c.config.CustomParams != "" {
 		dsn += " " + c.config.CustomParams
 	}
+	return dsn
+}
 
-	var err error
-	c.db, err = sql.Open("postgres", dsn)
+// openDB 根据 DSN 创建连接池，配置了 keepalive 时使用自定义拨号器；服务器的 NOTICE 等消息由 printNotice 输出
+func (c *CLI) openDB(dsn string) (*sql.DB, error) {
+	connector, err := pq.NewConnector(dsn)
 	if err != nil {
-		return err
+		return nil, err
 	}
-
-	// 设置连接池参数
-	c.db.SetMaxOpenConns(c.config.MaxOpenConns)
-	c.db.SetMaxIdleConns(c.config.MaxIdleConns)
-	c.db.SetConnMaxLifetime(c.config.ConnMaxLifetime)
-
-	if err := c.db.Ping(); err != nil {
-		c.db.Close()
-		return err
+	if keepaliveEnabled(c.config) {
+		connector.Dialer(newKeepaliveDialer(c.config))
 	}
+	return sql.OpenDB(pq.ConnectorWithNoticeHandler(connector, c.printNotice)), nil
+}
 
-	// 获取服务器信息
-	c.fetchServerInfo()
-
-	// 显示欢迎信息
-	c.showWelcome()
-
-	return nil
+// printNotice 输出服务器发送的 NOTICE、WARNING 等消息
+func (c *CLI) printNotice(notice *pq.Error) {
+	fmt.Fprintf(c.term, "%s\n", c.style(ansiYellow, fmt.Sprintf("%s:  %s", notice.Severity, notice.Message)))
 }
 
 // fetchServerInfo 获取服务器信息
 func (c *CLI) fetchServerInfo() {
+	ctx := context.Background()
+
 	var version string
-	c.db.QueryRow("SELECT version()").Scan(&version)
+	c.conn.QueryRowContext(ctx, "SELECT version()").Scan(&version)
 	c.serverInfo.Version = version
 
 	var serverEncoding, clientEncoding string
-	c.db.QueryRow("SHOW server_encoding").Scan(&serverEncoding)
-	c.db.QueryRow("SHOW client_encoding").Scan(&clientEncoding)
+	c.conn.QueryRowContext(ctx, "SHOW server_encoding").Scan(&serverEncoding)
+	c.conn.QueryRowContext(ctx, "SHOW client_encoding").Scan(&clientEncoding)
 	c.serverInfo.ServerEncoding = serverEncoding
 	c.serverInfo.ClientEncoding = clientEncoding
 
 	var connID int
-	c.db.QueryRow("SELECT pg_backend_pid()").Scan(&connID)
+	c.conn.QueryRowContext(ctx, "SELECT pg_backend_pid()").Scan(&connID)
 	c.serverInfo.ConnectionID = connID
+
+	var versionNum int
+	c.conn.QueryRowContext(ctx, "SHOW server_version_num").Scan(&versionNum)
+	c.serverInfo.VersionNum = versionNum
+	c.serverInfo.Major, c.serverInfo.Minor = splitVersionNum(versionNum)
+
+	c.conn.QueryRowContext(ctx, "SELECT rolsuper FROM pg_catalog.pg_roles WHERE rolname = current_user").Scan(&c.superuser)
 }
 
 // showWelcome 显示欢迎信息
@@ -197,98 +391,165 @@ func extractVersionNumber(version string) string {
 }
 
 // Start 启动交互式命令行
+// 输入不是 TTY 时按非交互方式逐条执行，读到 EOF 后返回；
+// 此时若设置了 ON_ERROR_STOP，遇到错误即返回该错误
 func (c *CLI) Start() error {
 	for {
+		// 输出空闲期间收到的异步通知
+		c.printNotifications()
+
 		// 设置提示符
 		prompt := c.getPrompt()
 		c.reader.SetPrompt(prompt)
 		
 		// 支持多行 SQL（以分号结束）
-		sqlStr := c.readMultiLine()
+		sqlStr, err := c.readMultiLine()
+		if err == io.EOF {
+			c.endOpenTransaction(true)
+			return nil
+		}
+		if err == errIdleTimeout {
+			fmt.Fprintf(c.term, "\nNo input for %v, closing idle session.\n", c.config.IdleTimeout)
+			// 读取器已关闭，无法询问
+			c.endOpenTransaction(false)
+			return nil
+		}
 		if sqlStr == "" {
 			continue
 		}
 
 		sqlStr = strings.TrimSpace(sqlStr)
+		c.addHistory(sqlStr)
 		
-		// 处理 psql 特殊命令（不需要分号）
-		if c.handlePsqlCommand(sqlStr) {
-			if strings.ToLower(sqlStr) == "exit" || strings.ToLower(sqlStr) == "quit" || 
-			   sqlStr == "\\q" {
-				return nil
-			}
-			continue
+		err = c.runStatement(sqlStr)
+		if err == errQuit {
+			c.endOpenTransaction(true)
+			return nil
+		}
+		if err != nil && !c.reader.Interactive() && c.boolVar("ON_ERROR_STOP") {
+			return err
+		}
+	}
+}
+
+// runStatement 执行一条输入：脚本命令、psql 特殊命令或 SQL
+func (c *CLI) runStatement(input string) error {
+	input = strings.TrimSpace(input)
+
+	// \set ECHO all 回显所有输入（包括反斜杠命令）
+	echo := strings.ToLower(c.vars["ECHO"])
+	if echo == "all" {
+		fmt.Fprintf(c.term, "%s\n", input)
+	}
+
+	// 处理脚本相关命令（\set、\i 等）
+	if handled, err := c.handleScriptCommand(input); handled {
+		return err
+	}
+
+	// 处理 psql 特殊命令（不需要分号）
+	if c.handlePsqlCommand(input) {
+		if strings.ToLower(input) == "exit" || strings.ToLower(input) == "quit" ||
+			input == "\\q" {
+			return errQuit
 		}
+		return nil
+	}
+
+	c.lastQuery = input
+	return c.runSQL(input)
+}
 
-		// 执行 SQL
-		c.executeSQL(sqlStr)
+// runSQL 执行 SQL（先替换 :var 变量引用），一行中的多条语句依次执行；
+// \bind 设置的参数只用于紧随其后的第一条语句
+func (c *CLI) runSQL(input string) error {
+	echo := strings.ToLower(c.vars["ECHO"])
+	params := c.bindParams
+	c.bindParams = nil
+	var firstErr error
+	for _, stmt := range splitStatements(c.interpolate(input)) {
+		// \set ECHO queries 在执行前回显替换变量后的 SQL
+		if echo == "queries" {
+			fmt.Fprintf(c.term, "%s\n", stmt)
+		}
+		c.lastRowCount, c.lastTag = -1, ""
+		c.stmtParams, params = params, nil
+		start := time.Now()
+		c.userSQL = true
+		err := c.executeSQL(stmt)
+		c.userSQL = false
+		elapsed := time.Since(start)
+		c.stmtParams = nil
+		c.logger.log(stmt, elapsed, c.lastRowCount, err)
+		if c.onStatement != nil {
+			c.onStatement(stmt, c.lastTag, c.lastRowCount, elapsed, err)
+		}
+		c.printNotifications()
+		if err != nil {
+			if c.singleTxn {
+				c.singleTxnFailed = true
+			}
+			if c.boolVar("ON_ERROR_STOP") {
+				return err
+			}
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
 	}
+	return firstErr
 }
 
 // getPrompt 获取提示符
 func (c *CLI) getPrompt() string {
-	if c.inTransaction {
-		return fmt.Sprintf("%s*=> ", c.database)
+	format, ok := c.vars["PROMPT1"]
+	if !ok {
+		format = defaultPrompt1
+	}
+	return c.expandPrompt(format, "=")
+}
+
+// getContinuationPrompt 获取多行输入的续行提示符，state 为续行状态（见 queryBuffer.state）
+func (c *CLI) getContinuationPrompt(state string) string {
+	format, ok := c.vars["PROMPT2"]
+	if !ok {
+		format = defaultPrompt2
 	}
-	return fmt.Sprintf("%s=> ", c.database)
+	return c.expandPrompt(format, state)
 }
 
 // readMultiLine 读取多行 SQL（以分号结束）
-func (c *CLI) readMultiLine() string {
-	var lines []string
-	
+// 读到 EOF 时先返回缓冲区中未结束的语句，之后返回 io.EOF
+func (c *CLI) readMultiLine() (string, error) {
+	var buf queryBuffer
+
 	for {
-		line, err := c.reader.ReadLine()
+		line, err := c.reader.ReadLineTimeout(c.config.IdleTimeout)
 		if err != nil {
 			if err == io.EOF {
-				return ""
-			}
-			return ""
-		}
-		
-		trimmed := strings.TrimSpace(line)
-		
-		// 空行继续等待输入
-		if trimmed == "" && len(lines) == 0 {
-			return ""
-		}
-		
-		// 如果是第一行，检查是否是特殊命令（不需要分号）
-		if len(lines) == 0 {
-			// 如果是 psql 命令（以反斜杠开头），直接返回
-			if strings.HasPrefix(trimmed, "\\") {
-				return trimmed
+				if !buf.empty() {
+					return buf.flush(), nil
+				}
+				return "", io.EOF
 			}
-			// 检查其他特殊命令（exit, quit, help）
-			cmdLower := strings.ToLower(trimmed)
-			if cmdLower == "exit" || cmdLower == "quit" || cmdLower == "help" {
-				return trimmed
+			if err == errIdleTimeout {
+				return "", err
 			}
+			return "", nil
 		}
-		
-		// 如果已经有输入且当前行是反斜杠命令，追加这行
-		if len(lines) > 0 && strings.HasPrefix(trimmed, "\\") {
-			lines = append(lines, line)
-			continue
-		}
-		
-		lines = append(lines, line)
-		
-		// 检查是否以分号结束
-		if strings.HasSuffix(trimmed, ";") {
-			break
+
+		if stmt, done := buf.add(line); done {
+			return stmt, nil
 		}
-		
+
 		// 设置多行提示符
-		c.reader.SetPrompt(fmt.Sprintf("%s-> ", c.database))
+		c.reader.SetPrompt(c.getContinuationPrompt(buf.state()))
 	}
-	
-	result := strings.Join(lines, "\n")
-	return result
 }
 
-// executeSQL 执行 SQL 语句
-func (c *CLI) executeSQL(sqlStr string) {
+// executeSQL 执行 SQL 语句，执行后更新事务状态
+func (c *CLI) executeSQL(sqlStr string) (err error) {
+	defer func() { c.trackTransaction(err) }()
 	startTime := time.Now()
 	
 	// 移除末尾的分号
@@ -296,65 +557,91 @@ func (c *CLI) executeSQL(sqlStr string) {
 	sqlStr = strings.TrimSpace(sqlStr)
 	
 	if sqlStr == "" {
-		return
+		return nil
+	}
+
+	if err := c.checkReadOnly(sqlStr); err != nil {
+		c.printError(err)
+		return err
+	}
+	if !c.confirmDestructive(sqlStr) {
+		fmt.Fprintf(c.term, "Statement cancelled.\n")
+		return nil
+	}
+	if !c.confirmExpensive(sqlStr) {
+		fmt.Fprintf(c.term, "Statement cancelled.\n")
+		return nil
+	}
+	if c.config.WarnExpensive {
+		// EXPLAIN 与等待确认的时间不计入语句耗时
+		startTime = time.Now()
 	}
 	
-	// 检查是否是事务命令
-	upperSQL := strings.ToUpper(sqlStr)
-	if upperSQL == "BEGIN" || upperSQL == "START TRANSACTION" {
-		c.inTransaction = true
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-		defer cancel()
-		_, err := c.db.ExecContext(ctx, "BEGIN")
-		if err != nil {
-			fmt.Fprintf(c.term, "ERROR: %v\n", err)
-			return
-		}
-		fmt.Fprintf(c.term, "BEGIN\n")
-		if c.timingEnabled {
-			fmt.Fprintf(c.term, "Time: %.3f ms\n", time.Since(startTime).Seconds()*1000)
+	// 检查是否是事务命令（ROLLBACK TO SAVEPOINT 等不改变事务状态的语句除外）
+	upperSQL := strings.ToUpper(stripLeadingComments(sqlStr))
+	txn := transactionCommand(sqlStr)
+	if c.singleTxn {
+		// 单事务模式下由外层统一 BEGIN/COMMIT，文件中的事务控制语句不能提前结束事务
+		switch txn {
+		case "BEGIN", "COMMIT":
+			fmt.Fprintf(c.term, "%s\n", c.style(ansiYellow, fmt.Sprintf("NOTICE: %s ignored in single-transaction mode", upperSQL)))
+			return nil
+		case "ROLLBACK", "PREPARE TRANSACTION":
+			err := fmt.Errorf("%s is not allowed in single-transaction mode", txn)
+			c.printError(err)
+			return err
 		}
-		return
 	}
-	if upperSQL == "COMMIT" {
-		c.inTransaction = false
+	if txn == "BEGIN" {
 		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 		defer cancel()
-		_, err := c.db.ExecContext(ctx, "COMMIT")
+		_, err := c.conn.ExecContext(ctx, sqlStr)
 		if err != nil {
-			fmt.Fprintf(c.term, "ERROR: %v\n", err)
-			return
+			c.printError(err)
+			return err
 		}
-		fmt.Fprintf(c.term, "COMMIT\n")
-		if c.timingEnabled {
-			fmt.Fprintf(c.term, "Time: %.3f ms\n", time.Since(startTime).Seconds()*1000)
-		}
-		return
+		// 已在事务中时服务器只发出警告（由 printNotice 输出），事务继续
+		c.inTransaction = true
+		c.lastTag = "BEGIN"
+		fmt.Fprintf(c.out, "BEGIN\n")
+		c.printTiming(time.Since(startTime))
+		return nil
 	}
-	if upperSQL == "ROLLBACK" {
-		c.inTransaction = false
-		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-		defer cancel()
-		_, err := c.db.ExecContext(ctx, "ROLLBACK")
-		if err != nil {
-			fmt.Fprintf(c.term, "ERROR: %v\n", err)
-			return
-		}
-		fmt.Fprintf(c.term, "ROLLBACK\n")
-		if c.timingEnabled {
-			fmt.Fprintf(c.term, "Time: %.3f ms\n", time.Since(startTime).Seconds()*1000)
-		}
-		return
+	if txn == "COMMIT" || txn == "ROLLBACK" || txn == "PREPARE TRANSACTION" {
+		return c.endTransaction(sqlStr, txn, startTime)
 	}
 	
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	// 维护命令（VACUUM、CREATE INDEX 等）可能运行很久，不设超时并定期输出进度
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if m, ok := maintenanceCommand(sqlStr); ok {
+		if err := c.checkMaintenance(m); err != nil {
+			c.printError(err)
+			return err
+		}
+		ctx, cancel = context.WithCancel(context.Background())
+		defer c.watchProgress(m)()
+	} else {
+		ctx, cancel = context.WithTimeout(context.Background(), c.queryTimeout())
+	}
 	defer cancel()
+	// Ctrl-C 取消正在执行的语句
+	ctx, stopInterrupt := interruptContext(ctx)
+	defer stopInterrupt()
 	
-	if isQuery(sqlStr) {
-		c.executeQuery(ctx, sqlStr, startTime)
-	} else {
-		c.executeCommand(ctx, sqlStr, startTime)
+	// EXECUTE 按预备语句的定义决定显示方式与命令类型
+	stmt := c.resolveExecute(ctx, sqlStr)
+	if isCall(stmt) {
+		return c.executeCall(ctx, sqlStr, startTime)
+	}
+	if isQuery(stmt) {
+		err := c.executeQuery(ctx, sqlStr, startTime)
+		if err == nil {
+			c.lastTag = queryTag(stmt)
+		}
+		return err
 	}
+	return c.executeCommand(ctx, sqlStr, stmt, startTime)
 }
 
 // handlePsqlCommand 处理 psql 特殊命令
@@ -380,6 +667,12 @@ func (c *CLI) handlePsqlCommand(cmd string) bool {
 		return true
 	}
 	
+	// List databases with size, tablespace and description
+	if cmd == "\\l+" || cmd == "\\list+" {
+		c.executeSQL("SELECT d.datname AS \"Name\", pg_catalog.pg_get_userbyid(d.datdba) AS \"Owner\", pg_catalog.pg_encoding_to_char(d.encoding) AS \"Encoding\", d.datcollate AS \"Collate\", d.datctype AS \"Ctype\", CASE WHEN pg_catalog.has_database_privilege(d.datname, 'CONNECT') THEN pg_catalog.pg_size_pretty(pg_catalog.pg_database_size(d.datname)) ELSE 'No Access' END AS \"Size\", t.spcname AS \"Tablespace\", pg_catalog.shobj_description(d.oid, 'pg_database') AS \"Description\" FROM pg_catalog.pg_database d JOIN pg_catalog.pg_tablespace t ON d.dattablespace = t.oid ORDER BY d.datname")
+		return true
+	}
+	
 	// List databases
 	if cmd == "\\l" || cmd == "\\list" {
 		c.executeSQL("SELECT datname AS \"Name\", pg_catalog.pg_get_userbyid(datdba) AS \"Owner\", pg_catalog.pg_encoding_to_char(encoding) AS \"Encoding\" FROM pg_catalog.pg_database ORDER BY datname")
@@ -398,21 +691,95 @@ func (c *CLI) handlePsqlCommand(cmd string) bool {
 	}
 	
 	// List tables
-	if cmd == "\\dt" || cmd == "\\dt+" {
-		c.executeSQL("SELECT schemaname AS \"Schema\", tablename AS \"Name\", tableowner AS \"Owner\" FROM pg_catalog.pg_tables WHERE schemaname NOT IN ('pg_catalog', 'information_schema') ORDER BY schemaname, tablename")
+	if cmd == "\\dt" || cmd == "\\dt+" || strings.HasPrefix(cmd, "\\dt ") || strings.HasPrefix(cmd, "\\dt+ ") {
+		opts, err := parseListArgs(cmd)
+		if err != nil {
+			fmt.Fprintf(c.term, "%v\n", err)
+			return true
+		}
+		c.listTables(opts, strings.HasPrefix(cmd, "\\dt+"))
 		return true
 	}
 	
 	// List schemas
-	if cmd == "\\dn" || cmd == "\\dn+" {
+	if cmd == "\\dn" {
 		c.executeSQL("SELECT nspname AS \"Name\", pg_catalog.pg_get_userbyid(nspowner) AS \"Owner\" FROM pg_catalog.pg_namespace WHERE nspname !~ '^pg_' AND nspname <> 'information_schema' ORDER BY nspname")
 		return true
 	}
+	if cmd == "\\dn+" {
+		c.executeSQL("SELECT nspname AS \"Name\", pg_catalog.pg_get_userbyid(nspowner) AS \"Owner\", pg_catalog.array_to_string(nspacl, ', ') AS \"Access privileges\", pg_catalog.obj_description(oid, 'pg_namespace') AS \"Description\" FROM pg_catalog.pg_namespace WHERE nspname !~ '^pg_' AND nspname <> 'information_schema' ORDER BY nspname")
+		return true
+	}
+	
+	// Roll back the current transaction
+	if cmd == "\\abort" {
+		c.executeSQL("ROLLBACK")
+		return true
+	}
+	
+	// Copy the last result to the clipboard
+	if cmd == "\\clip" {
+		c.copyToClipboard()
+		return true
+	}
+	
+	// Show client and server versions
+	if cmd == "\\version" {
+		c.showVersion()
+		return true
+	}
+	
+	// Show the first rows of a table
+	if cmd == "\\preview" || strings.HasPrefix(cmd, "\\preview ") {
+		c.handlePreview(tableArgs(strings.TrimPrefix(cmd, "\\preview")))
+		return true
+	}
+
+	// Show a random sample of a table
+	if cmd == "\\random" || strings.HasPrefix(cmd, "\\random ") {
+		c.handleRandom(tableArgs(strings.TrimPrefix(cmd, "\\random")))
+		return true
+	}
+
+	// Count the rows of a table
+	if cmd == "\\count" || strings.HasPrefix(cmd, "\\count ") {
+		c.handleCount(strings.TrimPrefix(cmd, "\\count"))
+		return true
+	}
+
+	// Refresh a materialized view
+	if cmd == "\\refresh" || strings.HasPrefix(cmd, "\\refresh ") {
+		c.handleRefresh(tableArgs(strings.TrimPrefix(cmd, "\\refresh")))
+		return true
+	}
 	
-	// Describe table
+	// Copy data between a client-side file and a table
+	if cmd == "\\copy" || strings.HasPrefix(cmd, "\\copy ") {
+		c.handleCopy(strings.TrimPrefix(cmd, "\\copy"))
+		return true
+	}
+	
+	// Table size breakdown (optional name pattern)
+	if cmd == "\\d++" || strings.HasPrefix(cmd, "\\d++ ") {
+		c.listTableSizes(commandPattern(cmd))
+		return true
+	}
+	
+	// Describe table (\d+ adds column and table comments)
 	if strings.HasPrefix(cmd, "\\d ") {
 		tableName := strings.TrimSpace(cmd[3:])
-		c.describeTable(tableName)
+		c.describeTable(tableName, false)
+		return true
+	}
+	if strings.HasPrefix(cmd, "\\d+ ") {
+		tableName := strings.TrimSpace(cmd[4:])
+		c.describeTable(tableName, true)
+		return true
+	}
+	
+	// Object descriptions
+	if cmd == "\\dd" || strings.HasPrefix(cmd, "\\dd ") {
+		c.listDescriptions(commandPattern(cmd))
 		return true
 	}
 	
@@ -423,13 +790,23 @@ func (c *CLI) handlePsqlCommand(cmd string) bool {
 	}
 	
 	// List indexes
-	if cmd == "\\di" || cmd == "\\di+" {
-		c.executeSQL("SELECT schemaname AS \"Schema\", indexname AS \"Name\", tablename AS \"Table\" FROM pg_catalog.pg_indexes WHERE schemaname NOT IN ('pg_catalog', 'information_schema') ORDER BY schemaname, indexname")
+	if cmd == "\\di" || cmd == "\\di+" || strings.HasPrefix(cmd, "\\di ") || strings.HasPrefix(cmd, "\\di+ ") {
+		opts, err := parseListArgs(cmd)
+		if err != nil {
+			fmt.Fprintf(c.term, "%v\n", err)
+			return true
+		}
+		c.listIndexes(opts, strings.HasPrefix(cmd, "\\di+"))
 		return true
 	}
 	
 	// List sequences
 	if cmd == "\\ds" || cmd == "\\ds+" {
+		// pg_sequences 视图需要 PostgreSQL 10 及以上，旧版本改用 pg_class
+		if !c.serverInfo.AtLeast(10, 0) {
+			c.executeSQL("SELECT n.nspname AS \"Schema\", c.relname AS \"Name\", pg_catalog.pg_get_userbyid(c.relowner) AS \"Owner\" FROM pg_catalog.pg_class c LEFT JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace WHERE c.relkind = 'S' AND n.nspname NOT IN ('pg_catalog', 'information_schema') ORDER BY 1, 2")
+			return true
+		}
 		c.executeSQL("SELECT schemaname AS \"Schema\", sequencename AS \"Name\", sequenceowner AS \"Owner\" FROM pg_catalog.pg_sequences WHERE schemaname NOT IN ('pg_catalog', 'information_schema') ORDER BY schemaname, sequencename")
 		return true
 	}
@@ -440,34 +817,182 @@ func (c *CLI) handlePsqlCommand(cmd string) bool {
 		return true
 	}
 	
-	// List users/roles
-	if cmd == "\\du" || cmd == "\\du+" {
-		c.executeSQL("SELECT rolname AS \"Role name\", rolsuper AS \"Superuser\", rolinherit AS \"Inherit\", rolcreaterole AS \"Create role\", rolcreatedb AS \"Create DB\" FROM pg_catalog.pg_roles ORDER BY rolname")
+	// List data types
+	if cmd == "\\dT" {
+		c.executeSQL("SELECT n.nspname AS \"Schema\", pg_catalog.format_type(t.oid, NULL) AS \"Name\", pg_catalog.obj_description(t.oid, 'pg_type') AS \"Description\" FROM pg_catalog.pg_type t LEFT JOIN pg_catalog.pg_namespace n ON n.oid = t.typnamespace WHERE (t.typrelid = 0 OR (SELECT c.relkind = 'c' FROM pg_catalog.pg_class c WHERE c.oid = t.typrelid)) AND NOT EXISTS (SELECT 1 FROM pg_catalog.pg_type el WHERE el.oid = t.typelem AND el.typarray = t.oid) AND n.nspname NOT IN ('pg_catalog', 'information_schema') ORDER BY 1, 2")
+		return true
+	}
+	if cmd == "\\dT+" {
+		c.executeSQL("SELECT n.nspname AS \"Schema\", pg_catalog.format_type(t.oid, NULL) AS \"Name\", t.typname AS \"Internal name\", CASE WHEN t.typrelid <> 0 THEN 'tuple' WHEN t.typlen < 0 THEN 'var' ELSE t.typlen::text END AS \"Size\", pg_catalog.array_to_string(ARRAY(SELECT e.enumlabel FROM pg_catalog.pg_enum e WHERE e.enumtypid = t.oid ORDER BY e.enumsortorder), ', ') AS \"Elements\", pg_catalog.pg_get_userbyid(t.typowner) AS \"Owner\", pg_catalog.obj_description(t.oid, 'pg_type') AS \"Description\" FROM pg_catalog.pg_type t LEFT JOIN pg_catalog.pg_namespace n ON n.oid = t.typnamespace WHERE (t.typrelid = 0 OR (SELECT c.relkind = 'c' FROM pg_catalog.pg_class c WHERE c.oid = t.typrelid)) AND NOT EXISTS (SELECT 1 FROM pg_catalog.pg_type el WHERE el.oid = t.typelem AND el.typarray = t.oid) AND n.nspname NOT IN ('pg_catalog', 'information_schema') ORDER BY 1, 2")
+		return true
+	}
+	
+	// List domains
+	if cmd == "\\dD" || cmd == "\\dD+" {
+		c.executeSQL("SELECT n.nspname AS \"Schema\", t.typname AS \"Name\", pg_catalog.format_type(t.typbasetype, t.typtypmod) AS \"Type\", CASE WHEN t.typnotnull THEN 'not null' ELSE '' END AS \"Nullable\", t.typdefault AS \"Default\", pg_catalog.array_to_string(ARRAY(SELECT pg_catalog.pg_get_constraintdef(r.oid, true) FROM pg_catalog.pg_constraint r WHERE r.contypid = t.oid), ' ') AS \"Check\" FROM pg_catalog.pg_type t LEFT JOIN pg_catalog.pg_namespace n ON n.oid = t.typnamespace WHERE t.typtype = 'd' AND n.nspname NOT IN ('pg_catalog', 'information_schema') ORDER BY 1, 2")
+		return true
+	}
+	
+	// List aggregates, operators and conversions (optional name pattern)
+	if cmd == "\\da" || strings.HasPrefix(cmd, "\\da ") {
+		c.listAggregates(commandPattern(cmd))
+		return true
+	}
+	if cmd == "\\do" || strings.HasPrefix(cmd, "\\do ") {
+		c.listOperators(commandPattern(cmd))
+		return true
+	}
+	if cmd == "\\dc" || strings.HasPrefix(cmd, "\\dc ") {
+		c.listConversions(commandPattern(cmd))
+		return true
+	}
+	
+	// List foreign-data wrappers, servers, user mappings and foreign tables
+	if parts := strings.Fields(cmd); len(parts) > 0 {
+		switch command := strings.TrimSuffix(parts[0], "+"); command {
+		case "\\dew", "\\des", "\\deu", "\\det":
+			c.listForeign(command, strings.HasSuffix(parts[0], "+"), commandPattern(cmd))
+			return true
+		}
+	}
+	
+	// List text search configurations, dictionaries, parsers and templates
+	if parts := strings.Fields(cmd); len(parts) > 0 {
+		if _, ok := textSearchCatalogs[parts[0]]; ok {
+			c.listTextSearch(parts[0], commandPattern(cmd))
+			return true
+		}
+	}
+	
+	// Show function source (\sf+ adds line numbers)
+	if parts := strings.Fields(cmd); len(parts) > 0 && (parts[0] == "\\sf" || parts[0] == "\\sf+") {
+		c.showFunctionSource(strings.TrimSpace(cmd[len(parts[0]):]), parts[0] == "\\sf+")
+		return true
+	}
+	
+	// List tablespaces
+	if (cmd == "\\db" || cmd == "\\db+") && !c.requireVersion(9, 2, "\\db") {
+		return true
+	}
+	if cmd == "\\db" {
+		c.executeSQL("SELECT t.spcname AS \"Name\", r.rolname AS \"Owner\", pg_catalog.pg_tablespace_location(t.oid) AS \"Location\" FROM pg_catalog.pg_tablespace t JOIN pg_catalog.pg_roles r ON r.oid = t.spcowner ORDER BY t.spcname")
+		return true
+	}
+	if cmd == "\\db+" {
+		c.executeSQL("SELECT t.spcname AS \"Name\", r.rolname AS \"Owner\", pg_catalog.pg_tablespace_location(t.oid) AS \"Location\", pg_catalog.array_to_string(t.spcacl, ', ') AS \"Access privileges\", pg_catalog.array_to_string(t.spcoptions, ', ') AS \"Options\", pg_catalog.pg_size_pretty(pg_catalog.pg_tablespace_size(t.oid)) AS \"Size\", pg_catalog.shobj_description(t.oid, 'pg_tablespace') AS \"Description\" FROM pg_catalog.pg_tablespace t JOIN pg_catalog.pg_roles r ON r.oid = t.spcowner ORDER BY t.spcname")
+		return true
+	}
+	
+	// List users/roles (\dg is an alias), with group membership
+	if cmd == "\\du" || cmd == "\\dg" {
+		c.executeSQL("SELECT r.rolname AS \"Role name\", r.rolsuper AS \"Superuser\", r.rolinherit AS \"Inherit\", r.rolcreaterole AS \"Create role\", r.rolcreatedb AS \"Create DB\", pg_catalog.array_to_string(ARRAY(SELECT b.rolname FROM pg_catalog.pg_auth_members m JOIN pg_catalog.pg_roles b ON m.roleid = b.oid WHERE m.member = r.oid ORDER BY 1), ', ') AS \"Member of\" FROM pg_catalog.pg_roles r ORDER BY r.rolname")
+		return true
+	}
+	if cmd == "\\du+" || cmd == "\\dg+" {
+		c.executeSQL("SELECT r.rolname AS \"Role name\", r.rolsuper AS \"Superuser\", r.rolinherit AS \"Inherit\", r.rolcreaterole AS \"Create role\", r.rolcreatedb AS \"Create DB\", r.rolconnlimit AS \"Connection limit\", r.rolvaliduntil AS \"Valid until\", pg_catalog.array_to_string(ARRAY(SELECT b.rolname FROM pg_catalog.pg_auth_members m JOIN pg_catalog.pg_roles b ON m.roleid = b.oid WHERE m.member = r.oid ORDER BY 1), ', ') AS \"Member of\", pg_catalog.shobj_description(r.oid, 'pg_authid') AS \"Description\" FROM pg_catalog.pg_roles r ORDER BY r.rolname")
 		return true
 	}
 	
 	// Expanded display toggle
 	if cmd == "\\x" {
-		c.expandedMode = !c.expandedMode
-		if c.expandedMode {
-			fmt.Fprintf(c.term, "Expanded display is on.\n")
-		} else {
-			fmt.Fprintf(c.term, "Expanded display is off.\n")
+		c.handlePset([]string{"expanded"})
+		return true
+	}
+
+	// Aligned/unaligned output toggle
+	if cmd == "\\a" {
+		format := "unaligned"
+		if c.settings.Format == "unaligned" {
+			format = "aligned"
+		}
+		c.handlePset([]string{"format", format})
+		return true
+	}
+	
+	// Parameters for the next query ($1, $2, ...)
+	if cmd == "\\bind" || strings.HasPrefix(cmd, "\\bind ") {
+		c.bindParams = []interface{}{}
+		for _, arg := range parseArgs(strings.TrimSpace(strings.TrimPrefix(cmd, "\\bind"))) {
+			c.bindParams = append(c.bindParams, arg)
 		}
 		return true
 	}
+
+	// Connection pool statistics
+	if cmd == "\\poolstats" {
+		c.showPoolStats()
+		return true
+	}
+	
+	// Savepoints of the current transaction
+	if cmd == "\\sp" {
+		c.showSavepoints()
+		return true
+	}
+	
+	// Run a query repeatedly and report latency statistics
+	if cmd == "\\bench" || strings.HasPrefix(cmd, "\\bench ") {
+		c.bench(strings.TrimSpace(strings.TrimPrefix(cmd, "\\bench")))
+		return true
+	}
+	
+	// Show a query plan as a tree
+	if cmd == "\\plan" || strings.HasPrefix(cmd, "\\plan ") {
+		c.showPlan(strings.TrimPrefix(cmd, "\\plan"))
+		return true
+	}
+
+	// Re-run the last query periodically
+	if cmd == "\\watch" || strings.HasPrefix(cmd, "\\watch ") {
+		c.watch(strings.Fields(cmd)[1:])
+		return true
+	}
+	
+	// Table title
+	if cmd == "\\C" || strings.HasPrefix(cmd, "\\C ") {
+		c.handlePset([]string{"title", strings.TrimSpace(strings.TrimPrefix(cmd, "\\C"))})
+		return true
+	}
+	
+	// Output format options
+	if cmd == "\\pset" || strings.HasPrefix(cmd, "\\pset ") {
+		c.handlePset(parseArgs(strings.TrimSpace(strings.TrimPrefix(cmd, "\\pset"))))
+		return true
+	}
 	
 	// Timing toggle
-	if cmd == "\\timing" {
-		c.timingEnabled = !c.timingEnabled
-		if c.timingEnabled {
-			fmt.Fprintf(c.term, "Timing is on.\n")
+	if cmd == "\\timing" || strings.HasPrefix(cmd, "\\timing ") {
+		if err := c.settings.Set("timing", strings.Join(strings.Fields(cmd)[1:], " ")); err != nil {
+			fmt.Fprintf(c.term, "\\timing: %v\n", err)
+			return true
+		}
+		fmt.Fprintf(c.term, "%s\n", c.settings.Describe("timing"))
+		return true
+	}
+	
+	// Change password
+	if cmd == "\\password" || strings.HasPrefix(cmd, "\\password ") {
+		parts := strings.Fields(cmd)
+		if len(parts) >= 2 {
+			c.changePassword(parts[1])
 		} else {
-			fmt.Fprintf(c.term, "Timing is off.\n")
+			c.changePassword("")
 		}
 		return true
 	}
 	
+	// Show last error in verbose form
+	if cmd == "\\errverbose" {
+		c.showErrorVerbose()
+		return true
+	}
+	
+	// Change the application name of the session
+	if cmd == "\\setappname" || strings.HasPrefix(cmd, "\\setappname ") {
+		c.handleSetAppName(strings.TrimPrefix(cmd, "\\setappname"))
+		return true
+	}
+	
 	// Connection info
 	if cmd == "\\conninfo" {
 		c.showConnectionInfo()
@@ -485,38 +1010,40 @@ func (c *CLI) handlePsqlCommand(cmd string) bool {
 
 // connectToDatabase 连接到指定数据库
 func (c *CLI) connectToDatabase(dbName string) {
-	dsn := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=disable connect_timeout=10",
-		c.config.Host, c.config.Port, c.config.Username, c.config.Password, dbName)
-	
-	newDB, err := sql.Open("postgres", dsn)
+	newDB, newConn, hp, err := c.dial(dbName)
 	if err != nil {
-		fmt.Fprintf(c.term, "ERROR: %v\n", err)
+		c.printError(err)
 		return
 	}
 	
-	if err := newDB.Ping(); err != nil {
-		newDB.Close()
-		fmt.Fprintf(c.term, "ERROR: database \"%s\" does not exist\n", dbName)
-		return
+	// 关闭旧连接，使用新连接；监听随旧连接一起取消
+	c.closeListener()
+	if c.conn != nil {
+		c.conn.Close()
 	}
-	
-	// 关闭旧连接，使用新连接
 	if c.db != nil {
 		c.db.Close()
 	}
 	c.db = newDB
+	c.conn = newConn
+	c.host, c.port = hp.host, hp.port
 	c.database = dbName
 	
 	fmt.Fprintf(c.term, "You are now connected to database \"%s\" as user \"%s\".\n", dbName, c.config.Username)
 }
 
-// describeTable 描述表结构
-func (c *CLI) describeTable(tableName string) {
+// describeTable 描述表结构，verbose（\d+）时增加列注释并在末尾输出表注释
+func (c *CLI) describeTable(tableName string, verbose bool) {
+	description := ""
+	if verbose {
+		description = `,
+			pg_catalog.col_description(a.attrelid, a.attnum) AS "Description"`
+	}
 	query := fmt.Sprintf(`
 		SELECT 
 			a.attname AS "Column",
 			pg_catalog.format_type(a.atttypid, a.atttypmod) AS "Type",
-			CASE WHEN a.attnotnull THEN 'not null' ELSE '' END AS "Modifiers"
+			CASE WHEN a.attnotnull THEN 'not null' ELSE '' END AS "Modifiers"%s
 		FROM pg_catalog.pg_attribute a
 		WHERE a.attrelid = (
 			SELECT c.oid FROM pg_catalog.pg_class c
@@ -524,14 +1051,14 @@ func (c *CLI) describeTable(tableName string) {
 			WHERE c.relname = '%s' AND n.nspname = 'public'
 		) AND a.attnum > 0 AND NOT a.attisdropped
 		ORDER BY a.attnum
-	`, tableName)
+	`, description, tableName)
 	
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 	
-	rows, err := c.db.QueryContext(ctx, query)
+	rows, err := c.conn.QueryContext(ctx, query)
 	if err != nil {
-		fmt.Fprintf(c.term, "ERROR: %v\n", err)
+		c.printError(err)
 		return
 	}
 	defer rows.Close()
@@ -539,17 +1066,9 @@ func (c *CLI) describeTable(tableName string) {
 	fmt.Fprintf(c.term, "Table \"%s\"\n", tableName)
 	
 	cols, _ := rows.Columns()
-	colWidths := []int{10, 20, 15}
-	
-	c.printSeparator(colWidths)
-	fmt.Fprintf(c.term, "| ")
-	for i, col := range cols {
-		fmt.Fprintf(c.term, "%-*s | ", colWidths[i], col)
-	}
-	fmt.Fprintf(c.term, "\n")
-	c.printSeparator(colWidths)
-	
-	count := 0
+	colTypes, _ := rows.ColumnTypes()
+
+	var allRows [][]string
 	for rows.Next() {
 		vals := make([]interface{}, len(cols))
 		valPtrs := make([]interface{}, len(cols))
@@ -558,20 +1077,17 @@ func (c *CLI) describeTable(tableName string) {
 		}
 		rows.Scan(valPtrs...)
 		
-		fmt.Fprintf(c.term, "| ")
+		rowStrs := make([]string, len(vals))
 		for i, v := range vals {
-			var str string
-			if v == nil {
-				str = ""
-			} else {
-				str = fmt.Sprintf("%v", v)
-			}
-			fmt.Fprintf(c.term, "%-*s | ", colWidths[i], str)
+			rowStrs[i] = c.formatValue(v, colTypes[i])
 		}
-		fmt.Fprintf(c.term, "\n")
-		count++
+		allRows = append(allRows, rowStrs)
+	}
+	c.renderTable(cols, allRows, tableOptions{})
+	c.describePartitions(tableName)
+	if verbose {
+		c.describeComment(tableName)
 	}
-	c.printSeparator(colWidths)
 	fmt.Fprintf(c.term, "\n")
 }
 
@@ -585,29 +1101,107 @@ General
 Connection
   \\c [DBNAME]            connect to new database
   \\conninfo              display information about connection
+  \\setappname [NAME]     set the application_name shown in pg_stat_activity for this session
+  \\version               show client and server versions
+  \\poolstats             display connection pool statistics
+  \\password [USERNAME]   securely change the password for a user
 
 Informational
   \\d [NAME]              describe table, view, sequence, or index
-  \\dt[+]                 list tables
+  \\d+ NAME               describe table with column and table comments
+  \\dd [PATTERN]          show object descriptions not displayed elsewhere (constraints, triggers, rules, ...)
+  \\d++ [PATTERN]         table, index and TOAST sizes with row estimates
+  \\dt[+] [PATTERN]       list tables (visible in search_path unless PATTERN names a schema; + adds size)
   \\dv[+]                 list views
-  \\di[+]                 list indexes
+  \\di[+] [PATTERN]       list indexes with their columns (+ adds method and size)
+                          \\dt and \\di accept --sort=name|size and --asc/--desc (size sorts largest first)
   \\ds[+]                 list sequences
   \\df[+]                 list functions
-  \\dn[+]                 list schemas
-  \\du[+]                 list roles
-  \\l, \\list             list databases
+  \\dn[+]                 list schemas (+ adds privileges, description)
+  \\du[+], \\dg[+]         list roles with membership (+ adds limits, expiry)
+  \\db[+]                 list tablespaces
+  \\dT[+]                 list data types
+  \\dD                    list domains
+  \\da [PATTERN]          list aggregates
+  \\do [PATTERN]          list operators
+  \\dc [PATTERN]          list conversions
+  \\dF[d|p|t] [PATTERN]   list text search configurations (dictionaries, parsers, templates)
+  \\dew[+] [PATTERN]      list foreign-data wrappers
+  \\des[+] [PATTERN]      list foreign servers
+  \\deu[+] [PATTERN]      list user mappings
+  \\det[+] [PATTERN]      list foreign tables
+  \\sf[+] FUNCNAME        show a function's definition (+ adds line numbers)
+  \\l[+], \\list[+]       list databases (+ adds size, tablespace, description)
 
 Formatting
   \\x                     toggle expanded output
-  \\timing                toggle timing of commands
+  \\a                     toggle between aligned and unaligned output format
+  \\pset [NAME [VALUE]]   set a table output option, or show all options
+  \\pset border [0|1|2]   set table border style
+  \\C [STRING]            set table title, or unset if none
+  \\pset numericlocale [on|off] group digits of numeric columns with the locale's separators (LC_NUMERIC, LANG)
+  \\pset arrays [pretty|raw] show arrays as JSON arrays and records with spacing
+  \\pset bytea [hex|length] show bytea as \\x hex or as its length
+  \\pset pager [on|off]    page long results with a built-in -- More -- prompt
+  \\pset binary [on|off]   receive integer, bytea and uuid columns in binary format
+  \\pset timeformat [LAYOUT|default] Go time layout for timestamp columns
+  \\pset fields [COL,...]  show only these columns in expanded mode (no argument shows all)
+  \\pset columns [N|auto] wrap expanded values to a total width of N (auto: terminal width, 0 disables)
+  \\pset colwidth [COL=N,...] truncate these columns at N characters instead of 50 (0 shows them in full)
+  \\pset format [aligned|unaligned|csv|insert [TABLE]|template] set output format (all but aligned ignore \\x and maxrows;
+                          unaligned separates values with fieldsep,
+                          insert prints INSERT statements into TABLE or the queried table,
+                          template renders the Go text/template in \\set templatefile PATH)
+  \\pset fieldsep [STRING] set the field separator for unaligned output (default "|")
+  \\pset null [STRING]    set the string printed in place of a NULL value
+  \\pset tuples_only [on|off] print only rows, without column names, title or row count
+  \\pset csv_fieldsep [C]  set the CSV field separator (",", ";", "tab", ...)
+  \\pset csv_bom [on|off]  write a UTF-8 byte order mark at the start of a CSV file opened with \\o
+  \\timing [on|off|detail] toggle timing of commands (detail splits execution/rendering)
+  \\watch [-d] [i=SEC] [c=N] re-run the last query every SEC seconds (-d highlights changes)
+  \\waitfor "QUERY" [SEC] poll QUERY every second until it returns true (or SEC seconds pass)
+  \\bind [PARAM]...       set query parameters ($1, $2, ...) for the next statement
+  \\sp                    show the savepoints of the current transaction
+  \\bench N [w=W] [QUERY] run QUERY (or the last query) N times after W warmup runs and show latency stats
+  \\plan [analyze] [QUERY] show the plan of QUERY (or the last query) as a tree with costs and row estimates
+  \\preview TABLE [N]     show the first N rows of TABLE (default 10)
+  \\random TABLE [N|P%]   show N random rows (default 10) or a P% TABLESAMPLE of TABLE
+  \\count TABLE [WHERE COND] print the number of rows of TABLE (matching COND)
+  \\refresh NAME [concurrently] refresh a materialized view without the statement time limit
 
 Transaction
   BEGIN                   start a transaction
   COMMIT                  commit current transaction
   ROLLBACK                rollback current transaction
+  \\abort                 same as ROLLBACK
+  CALL PROC(...)          run a procedure; one that commits or rolls back must be the only statement
+                          on its line and run outside BEGIN and single-transaction mode
 
 Query Buffer
   \\h [NAME]              help on syntax of SQL commands
+  \\errverbose            show most recent error message at maximum verbosity
+  \\s [FILE]              display history or save it to file
+
+Input/Output
+  \\i FILE                execute commands from file
+  \\copy ...              perform SQL COPY with data stream to the client host
+  \\o [FILE]              send query results to file (>>FILE appends), or back to the terminal
+  \\o |tee [-a] FILE      send query results to both the terminal and FILE (-a appends)
+  \\clip                  copy the last query result to the system clipboard (needs AllowShell)
+  \\script [-a] [FILE]    record the whole session (prompts, input and output) to FILE, or stop recording
+
+Variables
+  \\set [NAME [VALUE]]    set internal variable, or list all if no parameters
+  \\unset NAME            unset (delete) internal variable
+  \\setenv NAME [VALUE]   set or unset environment variable (process-wide)
+  \\prompt [-p] [TEXT] NAME
+                          prompt user to set internal variable (-p masks input)
+  :NAME, :'NAME', :"NAME" substitute variable as-is, as literal, or as identifier
+  ON_ERROR_STOP           stop executing a file (\\i) after the first error
+  STATEMENT_TIMEOUT       statement timeout for this session (5s, 500ms, 0 for none)
+  ECHO                    none, queries (echo SQL before running it) or all (echo all input)
+  PROMPT1, PROMPT2        prompt formats: %n user, %/ database, %m host, %> port,
+                          %x transaction status, %# superuser mark, %R =/-/'/(, %% percent
 
 `
 	fmt.Fprintf(c.term, help)
@@ -632,11 +1226,58 @@ func (c *CLI) showSQLHelp(cmd string) {
 // showConnectionInfo 显示连接信息
 func (c *CLI) showConnectionInfo() {
 	fmt.Fprintf(c.term, "You are connected to database \"%s\" as user \"%s\" via socket in \"%s\" at port \"%d\".\n",
-		c.database, c.config.Username, c.config.Host, c.config.Port)
+		c.database, c.config.Username, c.host, c.port)
+	if keepaliveEnabled(c.config) {
+		fmt.Fprintf(c.term, "TCP keepalives: idle %v, interval %v, count %d (0 means system default).\n",
+			c.config.KeepalivesIdle, c.config.KeepalivesInterval, c.config.KeepalivesCount)
+	}
+	fmt.Fprintf(c.term, "Application name: %s\n", c.config.ApplicationName)
+	fmt.Fprintf(c.term, "Connection string: %s\n", c.ConnectionString())
+}
+
+// changePassword 修改角色密码（\password），用户名为空时修改当前用户
+// 密码以掩码方式输入两次，不回显、不记入历史
+func (c *CLI) changePassword(username string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if username == "" {
+		if err := c.conn.QueryRowContext(ctx, "SELECT current_user").Scan(&username); err != nil {
+			c.printError(err)
+			return
+		}
+	}
+
+	password, err := c.reader.ReadPassword(fmt.Sprintf("Enter new password for user \"%s\": ", username))
+	if err != nil {
+		return
+	}
+	confirm, err := c.reader.ReadPassword("Enter it again: ")
+	if err != nil {
+		return
+	}
+	if password != confirm {
+		fmt.Fprintf(c.term, "Passwords didn't match.\n")
+		return
+	}
+
+	query := fmt.Sprintf("ALTER ROLE %s PASSWORD %s", pq.QuoteIdentifier(username), pq.QuoteLiteral(password))
+	if _, err := c.conn.ExecContext(ctx, query); err != nil {
+		c.printError(err)
+	}
 }
 
 // Close 关闭数据库连接
 func (c *CLI) Close() error {
+	c.logger.close()
+	c.logger = nil
+	c.closeListener()
+	c.closeOutput()
+	c.endOpenTransaction(false)
+	c.stopTranscript()
+	if c.conn != nil {
+		c.conn.Close()
+	}
 	if c.db != nil {
 		return c.db.Close()
 	}
@@ -644,40 +1285,110 @@ func (c *CLI) Close() error {
 }
 
 // executeQuery 执行查询语句
-func (c *CLI) executeQuery(ctx context.Context, sqlStr string, startTime time.Time) {
-	rows, err := c.db.QueryContext(ctx, sqlStr)
+func (c *CLI) executeQuery(ctx context.Context, sqlStr string, startTime time.Time) error {
+	rows, closeRows, err := c.queryRows(ctx, sqlStr)
 	if err != nil {
 		c.printError(err)
-		return
+		return err
 	}
-	defer rows.Close()
+	defer closeRows()
+
+	// QueryContext 返回时服务器已开始返回结果，之后的耗时主要用于读取与渲染
+	execTime := time.Since(startTime)
+
+	defer c.startPager()()
+	defer c.captureResult()()
+	// 一条语句可能返回多个结果集（如 lib/pq 以简单查询协议执行的多条语句），依次输出，之间以空行分隔
+	var rowCount int64
+	for {
+		n, err := c.displayResultSet(rows, sqlStr)
+		if err != nil {
+			c.printError(err)
+			return err
+		}
+		rowCount += int64(n)
+		if !rows.NextResultSet() {
+			break
+		}
+		fmt.Fprintf(c.out, "\n")
+	}
+	if err := rows.Err(); err != nil {
+		c.printError(err)
+		return err
+	}
+	c.lastRowCount = rowCount
+
+	c.printQueryTiming(time.Since(startTime), execTime)
+	// CSV 等导出格式输出后不加空行，便于直接导出为文件
+	switch c.settings.Format {
+	case "csv", "insert", "template":
+	default:
+		fmt.Fprintf(c.out, "\n")
+	}
+	return nil
+}
 
+// displayResultSet 按当前输出格式显示一个结果集，返回行数
+func (c *CLI) displayResultSet(rows *sql.Rows, sqlStr string) (int, error) {
 	cols, _ := rows.Columns()
 	colTypes, _ := rows.ColumnTypes()
-	
-	if c.expandedMode {
-		c.displayExpanded(rows, cols, startTime)
-	} else {
-		c.displayTable(rows, cols, colTypes, startTime)
+
+	switch {
+	case c.settings.Format == "csv":
+		return c.displayCSV(rows, cols, colTypes), nil
+	case c.settings.Format == "insert":
+		return c.displayInsert(rows, cols, colTypes, sqlStr), nil
+	case c.settings.Format == "template":
+		return c.displayTemplate(rows, cols, colTypes)
+	case c.settings.Format == "unaligned":
+		return c.displayUnaligned(rows, cols, colTypes), nil
+	case c.settings.Expanded:
+		return c.displayExpanded(rows, cols, colTypes), nil
+	default:
+		return c.displayTable(rows, cols, colTypes), nil
 	}
 }
 
-// displayTable 以表格形式显示结果
-func (c *CLI) displayTable(rows *sql.Rows, cols []string, colTypes []*sql.ColumnType, startTime time.Time) {
-	// 计算每列的最大宽度
-	colWidths := make([]int, len(cols))
-	for i, col := range cols {
-		colWidths[i] = len(col)
-		if colWidths[i] < 4 {
-			colWidths[i] = 4
-		}
-		if colWidths[i] > 50 {
-			colWidths[i] = 50
+// queryRows 执行查询，返回的 closeRows 在读取完结果后调用
+// \pset binary on 时先在服务器端准备语句再执行：lib/pq 只在预备语句的结果中使用二进制格式，
+// 整数、bytea 与 uuid 列因此无需解析文本（bytea 无需解码十六进制），其余类型仍以文本接收
+func (c *CLI) queryRows(ctx context.Context, sqlStr string) (*sql.Rows, func(), error) {
+	if !c.settings.Binary {
+		rows, err := c.conn.QueryContext(ctx, sqlStr, c.stmtParams...)
+		if err != nil {
+			return nil, nil, err
 		}
+		return rows, func() { rows.Close() }, nil
 	}
-	
+
+	stmt, err := c.conn.PrepareContext(ctx, sqlStr)
+	if err != nil {
+		return nil, nil, err
+	}
+	rows, err := stmt.QueryContext(ctx, c.stmtParams...)
+	if err != nil {
+		stmt.Close()
+		return nil, nil, err
+	}
+	// 语句须在结果读取完后关闭，否则关闭消息会与未读完的结果交错
+	return rows, func() {
+		rows.Close()
+		stmt.Close()
+	}, nil
+}
+
+// maxCellWidth 查询结果中单元格的默认最大显示宽度，可用 \pset colwidth 按列覆盖
+const maxCellWidth = 50
+
+// displayTable 以表格形式显示结果，返回结果集总行数
+func (c *CLI) displayTable(rows *sql.Rows, cols []string, colTypes []*sql.ColumnType) int {
 	// 收集所有行数据
 	var allRows [][]string
+	widths := make([]int, len(cols))
+	for i, col := range cols {
+		widths[i] = c.settings.columnWidth(col)
+	}
+	group, decimal := numericSeparators()
 	for rows.Next() {
 		vals := make([]interface{}, len(cols))
 		valPtrs := make([]interface{}, len(cols))
@@ -687,102 +1398,67 @@ func (c *CLI) displayTable(rows *sql.Rows, cols []string, colTypes []*sql.Column
 		rows.Scan(valPtrs...)
 		
 		rowStrs := make([]string, len(vals))
+		raw := make([]string, len(vals))
 		for i, v := range vals {
-			if v == nil {
-				rowStrs[i] = ""
-			} else {
-				switch val := v.(type) {
-				case []byte:
-					rowStrs[i] = string(val)
-				case time.Time:
-					rowStrs[i] = val.Format("2006-01-02 15:04:05")
-				case bool:
-					if val {
-						rowStrs[i] = "t"
-					} else {
-						rowStrs[i] = "f"
-					}
-				default:
-					rowStrs[i] = fmt.Sprintf("%v", v)
-				}
+			var colType *sql.ColumnType
+			if i < len(colTypes) {
+				colType = colTypes[i]
 			}
-			
-			// 更新列宽
-			if len(rowStrs[i]) > colWidths[i] {
-				if len(rowStrs[i]) > 50 {
-					colWidths[i] = 50
-					rowStrs[i] = rowStrs[i][:47] + "..."
-				} else {
-					colWidths[i] = len(rowStrs[i])
-				}
+			rowStrs[i] = c.formatValue(v, colType)
+			if v != nil && c.settings.NumericLocale && colType != nil && isNumericType(colType) {
+				rowStrs[i] = groupDigits(rowStrs[i], group, decimal)
+			}
+			// 先截断再加样式，避免截断 ANSI 转义序列
+			if widths[i] > 0 {
+				rowStrs[i] = truncateCell(rowStrs[i], widths[i])
+			}
+			raw[i] = rowStrs[i]
+			switch {
+			case c.watchDiff && c.watchChanged(len(allRows), i, rowStrs[i]):
+				rowStrs[i] = c.style(ansiReverse, rowStrs[i])
+			case v == nil:
+				rowStrs[i] = c.style(ansiDim, rowStrs[i])
 			}
 		}
+		if c.watchDiff {
+			c.watchCurr = append(c.watchCurr, raw)
+		}
 		allRows = append(allRows, rowStrs)
 		
-		if len(allRows) >= c.maxRows {
+		if c.settings.MaxRows > 0 && len(allRows) >= c.settings.MaxRows {
 			break
 		}
 	}
 	
-	// 打印表头
-	fmt.Fprintf(c.term, " ")
-	for i, col := range cols {
-		fmt.Fprintf(c.term, "%-*s ", colWidths[i], col)
-		if i < len(cols)-1 {
-			fmt.Fprintf(c.term, "| ")
-		}
-	}
-	fmt.Fprintf(c.term, "\n")
+	// 单元格已按列截断，渲染时不再统一截断
+	c.renderTable(cols, allRows, tableOptions{minColWidth: 4})
 	
-	// 打印分隔线
-	for i, width := range colWidths {
-		fmt.Fprintf(c.term, "%s", strings.Repeat("-", width+1))
-		if i < len(colWidths)-1 {
-			fmt.Fprintf(c.term, "+-")
+	// 打印统计信息
+	rowCount := len(allRows)
+	if c.settings.MaxRows > 0 && rowCount >= c.settings.MaxRows {
+		if total := rowCount + countRemaining(rows); total > rowCount {
+			c.printTruncated(rowCount, total)
+			return total
 		}
 	}
-	fmt.Fprintf(c.term, "\n")
-	
-	// 打印数据行
-	for _, row := range allRows {
-		fmt.Fprintf(c.term, " ")
-		for i, val := range row {
-			fmt.Fprintf(c.term, "%-*s ", colWidths[i], val)
-			if i < len(row)-1 {
-				fmt.Fprintf(c.term, "| ")
-			}
-		}
-		fmt.Fprintf(c.term, "\n")
+	if c.settings.TuplesOnly {
+		return rowCount
 	}
-	
-	// 打印统计信息
-	rowCount := len(allRows)
 	if rowCount == 0 {
-		fmt.Fprintf(c.term, "(0 rows)\n")
+		fmt.Fprintf(c.out, "(0 rows)\n")
 	} else if rowCount == 1 {
-		fmt.Fprintf(c.term, "(1 row)\n")
+		fmt.Fprintf(c.out, "(1 row)\n")
 	} else {
-		fmt.Fprintf(c.term, "(%d rows)\n", rowCount)
-	}
-	
-	if c.timingEnabled {
-		elapsed := time.Since(startTime).Seconds() * 1000
-		fmt.Fprintf(c.term, "Time: %.3f ms\n", elapsed)
-	}
-	fmt.Fprintf(c.term, "\n")
-}
-
-// printSeparator 打印表格分隔线
-func (c *CLI) printSeparator(colWidths []int) {
-	fmt.Fprintf(c.term, "+")
-	for _, width := range colWidths {
-		fmt.Fprintf(c.term, "%s+", strings.Repeat("-", width+2))
+		fmt.Fprintf(c.out, "(%d rows)\n", rowCount)
 	}
-	fmt.Fprintf(c.term, "\n")
+	return rowCount
 }
 
-// displayExpanded 以扩展形式显示结果
-func (c *CLI) displayExpanded(rows *sql.Rows, cols []string, startTime time.Time) {
+// displayExpanded 以扩展形式显示结果，返回结果集总行数
+func (c *CLI) displayExpanded(rows *sql.Rows, cols []string, colTypes []*sql.ColumnType) int {
+	c.printTitle(0)
+	shown := c.expandedColumns(cols)
+	width := c.outputWidth()
 	rowNum := 0
 	for rows.Next() {
 		rowNum++
@@ -793,70 +1469,145 @@ func (c *CLI) displayExpanded(rows *sql.Rows, cols []string, startTime time.Time
 		}
 		rows.Scan(valPtrs...)
 		
-		fmt.Fprintf(c.term, "-[ RECORD %d ]", rowNum)
-		fmt.Fprintf(c.term, "%s\n", strings.Repeat("-", 50-len(fmt.Sprintf("-[ RECORD %d ]", rowNum))))
+		// \pset tuples_only 时与 psql 一致不打印记录标题，记录之间以空行分隔
+		if !c.settings.TuplesOnly {
+			fmt.Fprintf(c.out, "-[ RECORD %d ]", rowNum)
+			fmt.Fprintf(c.out, "%s\n", strings.Repeat("-", 50-len(fmt.Sprintf("-[ RECORD %d ]", rowNum))))
+		} else if rowNum > 1 {
+			fmt.Fprintf(c.out, "\n")
+		}
 		
 		// 找出最长的列名
 		maxColLen := 0
-		for _, col := range cols {
-			if len(col) > maxColLen {
-				maxColLen = len(col)
+		for _, i := range shown {
+			if w := displayWidth(cols[i]); w > maxColLen {
+				maxColLen = w
 			}
 		}
 		
-		for i, col := range cols {
-			var valStr string
+		// 多行或超过目标宽度的值折行显示，续行与值的起始列对齐
+		indent := strings.Repeat(" ", maxColLen) + " | "
+		for _, i := range shown {
+			var colType *sql.ColumnType
+			if i < len(colTypes) {
+				colType = colTypes[i]
+			}
+			// 先按未加样式的文本折行，ANSI 转义序列不计入宽度
+			lines := wrapText(c.formatValue(vals[i], colType), width-maxColLen-3)
 			if vals[i] == nil {
-				valStr = ""
-			} else {
-				switch val := vals[i].(type) {
-				case []byte:
-					valStr = string(val)
-				case time.Time:
-					valStr = val.Format("2006-01-02 15:04:05")
-				case bool:
-					if val {
-						valStr = "t"
-					} else {
-						valStr = "f"
-					}
-				default:
-					valStr = fmt.Sprintf("%v", val)
+				for j := range lines {
+					lines[j] = c.style(ansiDim, lines[j])
 				}
 			}
-			fmt.Fprintf(c.term, "%-*s | %s\n", maxColLen, col, valStr)
+			fmt.Fprintf(c.out, "%s%s | %s\n", cols[i], strings.Repeat(" ", maxColLen-displayWidth(cols[i])), lines[0])
+			for _, line := range lines[1:] {
+				fmt.Fprintf(c.out, "%s%s\n", indent, line)
+			}
 		}
 		
-		if rowNum >= c.maxRows {
+		if c.settings.MaxRows > 0 && rowNum >= c.settings.MaxRows {
 			break
 		}
 	}
 	
-	if rowNum == 0 {
-		fmt.Fprintf(c.term, "(0 rows)\n")
+	if rowNum == 0 && !c.settings.TuplesOnly {
+		fmt.Fprintf(c.out, "(0 rows)\n")
 	}
-	
-	if c.timingEnabled {
-		elapsed := time.Since(startTime).Seconds() * 1000
-		fmt.Fprintf(c.term, "Time: %.3f ms\n", elapsed)
+	if c.settings.MaxRows > 0 && rowNum >= c.settings.MaxRows {
+		if total := rowNum + countRemaining(rows); total > rowNum {
+			c.printTruncated(rowNum, total)
+			return total
+		}
 	}
-	fmt.Fprintf(c.term, "\n")
+	return rowNum
+}
+
+// outputWidth 返回扩展模式折行的目标宽度：\pset columns 为正数时使用该值，为 0 时不折行；
+// 为 auto 时依次使用终端宽度、COLUMNS 环境变量，都无法确定时返回 0，不折行
+func (c *CLI) outputWidth() int {
+	if c.settings.Columns != ColumnsAuto {
+		return c.settings.Columns
+	}
+	if w := c.reader.Width(); w > 0 {
+		return w
+	}
+	if w, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && w > 0 {
+		return w
+	}
+	return 0
+}
+
+// expandedColumns 返回扩展模式下要显示的列下标（\pset fields，列名不区分大小写）
+// 未设置或没有匹配的列时显示全部列
+func (c *CLI) expandedColumns(cols []string) []int {
+	var shown []int
+	for i, col := range cols {
+		if len(c.settings.Fields) == 0 || containsFold(c.settings.Fields, col) {
+			shown = append(shown, i)
+		}
+	}
+	if len(shown) == 0 {
+		for i := range cols {
+			shown = append(shown, i)
+		}
+	}
+	return shown
+}
+
+// containsFold 判断列表中是否包含 s（不区分大小写）
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// countRemaining 统计结果集中尚未读取的行数
+func countRemaining(rows *sql.Rows) int {
+	n := 0
+	for rows.Next() {
+		n++
+	}
+	return n
+}
+
+// printTruncated 提示结果因 maxrows 被截断
+func (c *CLI) printTruncated(shown, total int) {
+	fmt.Fprintf(c.out, "(showing first %d of %d rows; use \\set maxrows to change)\n", shown, total)
 }
 
 // executeCommand 执行非查询语句
-func (c *CLI) executeCommand(ctx context.Context, sqlStr string, startTime time.Time) {
-	result, err := c.db.ExecContext(ctx, sqlStr)
+func (c *CLI) executeCommand(ctx context.Context, sqlStr, tagSQL string, startTime time.Time) error {
+	result, err := c.conn.ExecContext(ctx, sqlStr, c.stmtParams...)
 	if err != nil {
 		c.printError(err)
-		return
+		return err
 	}
 	
 	affected, _ := result.RowsAffected()
+	c.lastRowCount = affected
+	c.syncListener(tagSQL)
+	c.syncStatementTimeout(tagSQL)
+	c.syncApplicationName(tagSQL)
+	c.trackSavepoint(tagSQL)
 	
 	// 判断命令类型
-	upperSQL := strings.ToUpper(strings.TrimSpace(sqlStr))
+	upperSQL := strings.ToUpper(stripLeadingComments(tagSQL))
+	if strings.HasPrefix(upperSQL, "WITH") {
+		// 数据修改型 CTE 以主语句作为命令类型
+		if main := cteMainKeyword(topLevelWords(upperSQL)); main != "" {
+			upperSQL = main
+		}
+	}
+	// 与 psql 一致，只有数据修改语句与 COPY 带行数
 	var commandTag string
+	withCount := true
 	switch {
+	case isSelectInto(tagSQL):
+		// 与 psql 一致，报告写入新表的行数；在完整语句上判断，WITH 语句的 upperSQL 已只剩主语句关键字
+		commandTag = "SELECT"
 	case strings.HasPrefix(upperSQL, "INSERT"):
 		commandTag = "INSERT"
 	case strings.HasPrefix(upperSQL, "UPDATE"):
@@ -869,36 +1620,147 @@ func (c *CLI) executeCommand(ctx context.Context, sqlStr string, startTime time.
 		commandTag = "DROP"
 	case strings.HasPrefix(upperSQL, "ALTER"):
 		commandTag = "ALTER"
+	case strings.HasPrefix(upperSQL, "COPY"):
+		commandTag = "COPY"
 	default:
 		commandTag = "COMMAND"
+		words := topLevelWords(upperSQL)
+		if len(words) == 0 {
+			break
+		}
+		switch words[0] {
+		case "PREPARE", "SET", "RESET", "LISTEN", "NOTIFY", "UNLISTEN", "GRANT", "REVOKE", "COMMENT",
+			"VACUUM", "ANALYZE", "SAVEPOINT", "RELEASE":
+			commandTag, withCount = words[0], false
+		case "REFRESH":
+			commandTag, withCount = "REFRESH MATERIALIZED VIEW", false
+		case "ROLLBACK", "ABORT":
+			// ROLLBACK TO SAVEPOINT
+			commandTag, withCount = "ROLLBACK", false
+		case "DEALLOCATE":
+			commandTag, withCount = "DEALLOCATE", false
+			if words[len(words)-1] == "ALL" {
+				commandTag = "DEALLOCATE ALL"
+			}
+		case "DISCARD":
+			// DISCARD ALL、DISCARD PLANS 等
+			commandTag, withCount = strings.Join(words[:min(len(words), 2)], " "), false
+		}
 	}
+	c.lastTag = commandTag
 	
-	fmt.Fprintf(c.term, "%s %d\n", commandTag, affected)
+	if withCount {
+		fmt.Fprintf(c.out, "%s %d\n", commandTag, affected)
+	} else {
+		fmt.Fprintf(c.out, "%s\n", commandTag)
+	}
 	
-	if c.timingEnabled {
-		elapsed := time.Since(startTime).Seconds() * 1000
-		fmt.Fprintf(c.term, "Time: %.3f ms\n", elapsed)
+	c.printTiming(time.Since(startTime))
+	fmt.Fprintf(c.out, "\n")
+	return nil
+}
+
+// printTiming 计时开启时输出耗时
+func (c *CLI) printTiming(elapsed time.Duration) {
+	if !c.settings.Timing {
+		return
 	}
-	fmt.Fprintf(c.term, "\n")
+	fmt.Fprintf(c.term, "Time: %s\n", formatDuration(elapsed))
+}
+
+// formatDuration 以毫秒显示耗时，与 psql 14 一致，达到 1 秒时追加易读形式：
+//
+//	12.345 ms
+//	83456.789 ms (01:23.457)
+//	7200000.000 ms (02:00:00.000)
+//	90061000.000 ms (1 d 01:01:01.000)
+func formatDuration(d time.Duration) string {
+	ms := d.Seconds() * 1000
+	if ms < 1000 {
+		return fmt.Sprintf("%.3f ms", ms)
+	}
+
+	seconds := ms / 1000
+	minutes := math.Floor(seconds / 60)
+	seconds -= 60 * minutes
+	if minutes < 60 {
+		return fmt.Sprintf("%.3f ms (%02d:%06.3f)", ms, int(minutes), seconds)
+	}
+	hours := math.Floor(minutes / 60)
+	minutes -= 60 * hours
+	if hours < 24 {
+		return fmt.Sprintf("%.3f ms (%02d:%02d:%06.3f)", ms, int(hours), int(minutes), seconds)
+	}
+	days := math.Floor(hours / 24)
+	hours -= 24 * days
+	return fmt.Sprintf("%.3f ms (%.0f d %02d:%02d:%06.3f)", ms, days, int(hours), int(minutes), seconds)
+}
+
+// printQueryTiming 输出查询耗时，detail 模式下拆分为执行（等待服务器返回）与渲染两部分
+func (c *CLI) printQueryTiming(total, exec time.Duration) {
+	if !c.settings.Timing {
+		return
+	}
+	if !c.settings.TimingDetail {
+		c.printTiming(total)
+		return
+	}
+	fmt.Fprintf(c.term, "Time: %s (execution: %.3f ms, rendering: %.3f ms)\n",
+		formatDuration(total), exec.Seconds()*1000, (total - exec).Seconds()*1000)
 }
 
 // printError 打印错误信息
 func (c *CLI) printError(err error) {
+	c.lastErr = err
+
+	// 服务器错误附带 SQLSTATE 代码
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		severity := pqErr.Severity
+		if severity == "" {
+			severity = "ERROR"
+		}
+		fmt.Fprintf(c.term, "%s\n\n", c.style(ansiRed, fmt.Sprintf("%s:  %s (SQLSTATE %s)", severity, pqErr.Message, pqErr.Code)))
+		return
+	}
+
 	errMsg := err.Error()
-	fmt.Fprintf(c.term, "ERROR: %s\n\n", errMsg)
+	fmt.Fprintf(c.term, "%s\n\n", c.style(ansiRed, "ERROR: "+errMsg))
 }
 
-// isQuery 判断是否是查询语句
+// isQuery 判断是否是查询语句（返回结果集）
+// 通过解析顶层关键字判断：WITH 语句跳过 CTE 定义，按其后的主语句判断；
+// INSERT/UPDATE/DELETE/MERGE（包括数据修改型 CTE）仅在顶层带 RETURNING 时才返回结果集
 func isQuery(sqlStr string) bool {
-	upper := strings.ToUpper(strings.TrimSpace(sqlStr))
+	words := topLevelWords(stripLeadingComments(sqlStr))
+	if len(words) == 0 {
+		return false
+	}
+
+	keyword := words[0]
+	if keyword == "WITH" {
+		if main := cteMainKeyword(words[1:]); main != "" {
+			keyword = main
+		}
+	}
+
+	switch keyword {
+	case "INSERT", "UPDATE", "DELETE", "MERGE":
+		return containsWord(words, "RETURNING")
+	case "SELECT":
+		// SELECT ... INTO 创建新表，不返回结果集
+		if containsWord(words, "INTO") {
+			return false
+		}
+	}
 	
 	queryPrefixes := []string{
 		"SELECT", "SHOW", "WITH", "TABLE", "VALUES",
-		"EXPLAIN", "ANALYZE",
+		"EXPLAIN", "EXECUTE",
 	}
 	
 	for _, prefix := range queryPrefixes {
-		if strings.HasPrefix(upper, prefix) {
+		if keyword == prefix {
 			return true
 		}
 	}
@@ -0,0 +1,119 @@
+package postgres
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strings"
+)
+
+// ansiSequence 匹配 ANSI 样式转义序列，复制到剪贴板前去除
+var ansiSequence = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+// maxClipBytes 为 \clip 保留的查询结果输出上限，超过后不再保留
+const maxClipBytes = 1 << 20
+
+// resultCapture 保留最多 maxClipBytes 字节的查询结果输出；超过上限时丢弃已保留的内容并标记 truncated
+type resultCapture struct {
+	buf       bytes.Buffer
+	truncated bool
+}
+
+// Write 实现 io.Writer，总是报告写入成功，不影响查询结果的正常输出
+func (w *resultCapture) Write(p []byte) (int, error) {
+	if w.truncated {
+		return len(p), nil
+	}
+	if w.buf.Len()+len(p) > maxClipBytes {
+		w.buf.Reset()
+		w.truncated = true
+		return len(p), nil
+	}
+	return w.buf.Write(p)
+}
+
+// Reset 清空已保留的输出
+func (w *resultCapture) Reset() {
+	w.buf.Reset()
+	w.truncated = false
+}
+
+// captureResult 在 lastResult 中保留本次查询结果的输出（含标题与行数），供 \clip 使用，返回恢复输出的函数
+// 只保留 aligned 表格与扩展显示；csv、insert 与 template 常用于大量导出，不再额外占用一份内存
+func (c *CLI) captureResult() func() {
+	c.lastResult.Reset()
+	if c.settings.Format != "aligned" {
+		return func() {}
+	}
+	out := c.out
+	c.out = io.MultiWriter(out, &c.lastResult)
+	return func() { c.out = out }
+}
+
+// clipboardCommand 返回当前平台可用的剪贴板程序及参数：
+// macOS 为 pbcopy，Windows 为 clip.exe，其他系统依次尝试 wl-copy（Wayland）、xclip 与 xsel
+func clipboardCommand() (string, []string, error) {
+	var candidates [][]string
+	switch runtime.GOOS {
+	case "darwin":
+		candidates = [][]string{{"pbcopy"}}
+	case "windows":
+		candidates = [][]string{{"clip.exe"}}
+	default:
+		if os.Getenv("WAYLAND_DISPLAY") != "" {
+			candidates = append(candidates, []string{"wl-copy"})
+		}
+		candidates = append(candidates, []string{"xclip", "-selection", "clipboard"}, []string{"xsel", "--clipboard", "--input"})
+	}
+	for _, cand := range candidates {
+		if path, err := exec.LookPath(cand[0]); err == nil {
+			return path, cand[1:], nil
+		}
+	}
+	names := make([]string, len(candidates))
+	for i, cand := range candidates {
+		names[i] = cand[0]
+	}
+	return "", nil, fmt.Errorf("no clipboard utility found (tried %s)", strings.Join(names, ", "))
+}
+
+// copyToClipboard 处理 \clip：将最近一次查询结果（去除颜色）复制到系统剪贴板
+// 需要 Config.AllowShell，因为要在本机运行剪贴板程序；通过 SSH 使用时剪贴板位于服务端而非用户本机
+func (c *CLI) copyToClipboard() {
+	if !c.config.AllowShell {
+		fmt.Fprintf(c.term, "\\clip: running local programs is disabled (set Config.AllowShell to enable)\n")
+		return
+	}
+	if c.lastResult.truncated {
+		fmt.Fprintf(c.term, "\\clip: the last query result is larger than %d bytes and was not kept\n", maxClipBytes)
+		return
+	}
+	text := strings.TrimRight(ansiSequence.ReplaceAllString(c.lastResult.buf.String(), ""), "\n") + "\n"
+	if text == "\n" {
+		fmt.Fprintf(c.term, "\\clip: there is no query result to copy\n")
+		return
+	}
+
+	path, args, err := clipboardCommand()
+	if err != nil {
+		fmt.Fprintf(c.term, "\\clip: %v\n", err)
+		return
+	}
+	cmd := exec.Command(path, args...)
+	cmd.Stdin = strings.NewReader(text)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			err = errors.New(msg)
+		}
+		fmt.Fprintf(c.term, "\\clip: %v\n", err)
+		return
+	}
+	fmt.Fprintf(c.term, "Copied %d lines to the clipboard.\n", strings.Count(text, "\n"))
+}
@@ -0,0 +1,39 @@
+package postgres
+
+import (
+	"os"
+	"strings"
+)
+
+// ANSI 样式
+const (
+	ansiReset   = "\x1b[0m"
+	ansiBold    = "\x1b[1m"
+	ansiDim     = "\x1b[2m"
+	ansiReverse = "\x1b[7m"
+	ansiRed     = "\x1b[31m"
+	ansiYellow  = "\x1b[33m"
+)
+
+// useColor 根据 Config.Color（auto/always/never）决定是否输出颜色
+// auto 时仅在交互式终端且未设置 NO_COLOR 环境变量时启用
+func useColor(mode string, interactive bool) bool {
+	switch strings.ToLower(mode) {
+	case "always":
+		return true
+	case "never":
+		return false
+	}
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		return false
+	}
+	return interactive
+}
+
+// style 为文本加上 ANSI 样式，未启用颜色或文本为空时原样返回
+func (c *CLI) style(code, s string) string {
+	if !c.color || s == "" {
+		return s
+	}
+	return code + s + ansiReset
+}
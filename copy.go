@@ -0,0 +1,837 @@
+package postgres
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// copyCommand 解析后的 \copy 命令
+type copyCommand struct {
+	source  string // 表名（可带 schema）或括号括起的查询
+	columns string // 列列表（包括括号），可为空
+	from    bool   // FROM 为导入，否则为 TO 导出
+	file    string // 文件名，使用标准输入输出时为空
+	stdio   string // stdin、stdout、pstdin 或 pstdout，使用文件时为空
+	options string // 选项原文；FROM 时原样发送给服务器
+}
+
+// parseCopyCommand 解析 \copy 的参数，语法与 psql 相同：
+// {table [(column, ...)] | (query)} {FROM | TO} {'file' | file | stdin | stdout | pstdin | pstdout} [[WITH] options]
+func parseCopyCommand(args string) (copyCommand, error) {
+	var cmd copyCommand
+	s := strings.TrimSpace(args)
+	if s == "" {
+		return cmd, fmt.Errorf("\\copy: arguments required")
+	}
+
+	var err error
+	if s[0] == '(' {
+		if cmd.source, s, err = scanParenGroup(s); err != nil {
+			return cmd, err
+		}
+	} else {
+		cmd.source, s = scanCopyWord(s)
+		if s = strings.TrimSpace(s); strings.HasPrefix(s, "(") {
+			if cmd.columns, s, err = scanParenGroup(s); err != nil {
+				return cmd, err
+			}
+		}
+	}
+
+	var direction string
+	direction, s = scanCopyWord(strings.TrimSpace(s))
+	switch strings.ToUpper(direction) {
+	case "FROM":
+		cmd.from = true
+	case "TO":
+	default:
+		return cmd, copyParseError(direction + s)
+	}
+
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "'") {
+		if cmd.file, s, err = scanCopyFilename(s); err != nil {
+			return cmd, err
+		}
+	} else {
+		var target string
+		target, s = scanCopyWord(s)
+		switch strings.ToLower(target) {
+		case "":
+			return cmd, copyParseError("")
+		case "stdin", "stdout", "pstdin", "pstdout":
+			cmd.stdio = strings.ToLower(target)
+		case "program":
+			return cmd, fmt.Errorf("\\copy: PROGRAM is not supported")
+		default:
+			cmd.file = target
+		}
+	}
+	switch {
+	case cmd.from && (cmd.stdio == "stdout" || cmd.stdio == "pstdout"),
+		!cmd.from && (cmd.stdio == "stdin" || cmd.stdio == "pstdin"):
+		return cmd, copyParseError(cmd.stdio)
+	}
+
+	cmd.options = strings.TrimSpace(s)
+	return cmd, nil
+}
+
+// copyParseError 返回 psql 格式的解析错误，at 为出错位置之后的文本
+func copyParseError(at string) error {
+	if at = strings.TrimSpace(at); at == "" {
+		return fmt.Errorf("\\copy: parse error at end of line")
+	}
+	return fmt.Errorf("\\copy: parse error at \"%s\"", at)
+}
+
+// scanCopyWord 读取到空白或左括号为止的一个单词，双引号内的空白与括号属于单词
+func scanCopyWord(s string) (word, rest string) {
+	inQuote := false
+	for i := 0; i < len(s); i++ {
+		switch ch := s[i]; {
+		case ch == '"':
+			inQuote = !inQuote
+		case !inQuote && (ch == ' ' || ch == '\t' || ch == '\n' || ch == '('):
+			return s[:i], s[i:]
+		}
+	}
+	return s, ""
+}
+
+// scanParenGroup 读取以 ( 开头、括号配对的一段文本（忽略引号内的括号），返回包括括号的文本与剩余部分
+func scanParenGroup(s string) (group, rest string, err error) {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		switch {
+		case quote != 0:
+			if ch == quote {
+				quote = 0
+			}
+		case ch == '\'' || ch == '"':
+			quote = ch
+		case ch == '(':
+			depth++
+		case ch == ')':
+			depth--
+			if depth == 0 {
+				return s[:i+1], s[i+1:], nil
+			}
+		}
+	}
+	return "", "", copyParseError("")
+}
+
+// scanCopyFilename 读取单引号括起的文件名（连续两个单引号表示引号本身），返回去掉引号后的内容与剩余部分
+func scanCopyFilename(s string) (value, rest string, err error) {
+	var sb strings.Builder
+	for i := 1; i < len(s); i++ {
+		if s[i] != '\'' {
+			sb.WriteByte(s[i])
+			continue
+		}
+		if i+1 < len(s) && s[i+1] == '\'' {
+			sb.WriteByte('\'')
+			i++
+			continue
+		}
+		return sb.String(), s[i+1:], nil
+	}
+	return "", "", fmt.Errorf("\\copy: unterminated quoted string")
+}
+
+// handleCopy 处理 \copy：在客户端读写文件，通过 COPY FROM STDIN 或查询与服务器交换数据
+func (c *CLI) handleCopy(args string) {
+	cmd, err := parseCopyCommand(args)
+	if err != nil {
+		fmt.Fprintf(c.term, "%v\n", err)
+		return
+	}
+
+	var n int64
+	if cmd.from {
+		n, err = c.copyFrom(cmd)
+	} else {
+		n, err = c.copyTo(cmd)
+	}
+	c.trackTransaction(err)
+	if err != nil {
+		c.printError(err)
+		return
+	}
+	c.lastRowCount = n
+	fmt.Fprintf(c.out, "COPY %d\n", n)
+}
+
+// copyFrom 执行 \copy ... FROM：选项原样交给服务器的 COPY FROM STDIN 解析，数据按行原样发送
+func (c *CLI) copyFrom(cmd copyCommand) (int64, error) {
+	sqlStr := "COPY " + cmd.source
+	if cmd.columns != "" {
+		sqlStr += " " + cmd.columns
+	}
+	sqlStr += " FROM STDIN"
+	if cmd.options != "" {
+		sqlStr += " " + cmd.options
+	}
+	if err := c.checkReadOnly(sqlStr); err != nil {
+		return 0, err
+	}
+
+	var next func() (string, error)
+	if cmd.file != "" {
+		f, err := os.Open(cmd.file)
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", cmd.file, err)
+		}
+		defer f.Close()
+		br := bufio.NewReader(f)
+		next = func() (string, error) {
+			line, err := br.ReadString('\n')
+			if line == "" && err != nil {
+				return "", err
+			}
+			return strings.TrimSuffix(line, "\n"), nil
+		}
+	} else if cmd.stdio == "stdin" && c.scriptInput != nil {
+		// 脚本中的 stdin 是脚本本身：数据紧随 \copy 命令，以 \. 或文件末尾结束
+		scanner := c.scriptInput
+		ended := false
+		next = func() (string, error) {
+			if !scanner.Scan() {
+				ended = true
+				if err := scanner.Err(); err != nil {
+					return "", err
+				}
+				return "", io.EOF
+			}
+			if line := scanner.Text(); line != "\\." {
+				return line, nil
+			}
+			ended = true
+			return "", io.EOF
+		}
+		// 导入失败时跳过剩余的数据行，以免被当作 SQL 执行
+		defer func() {
+			for !ended {
+				next()
+			}
+		}()
+	} else {
+		if c.reader.Interactive() {
+			fmt.Fprintf(c.term, "Enter data to be copied followed by a newline.\nEnd with a backslash and a period on a line by itself, or an EOF signal.\n")
+			c.reader.SetPrompt(">> ")
+		}
+		next = func() (string, error) {
+			line, err := c.reader.ReadLine()
+			if err != nil || line == "\\." {
+				return "", io.EOF
+			}
+			return line, nil
+		}
+	}
+
+	ctx, stop := interruptContext(context.Background())
+	defer stop()
+
+	// lib/pq 只允许在事务中执行 COPY FROM STDIN，不在事务中时使用一个临时事务
+	if c.inTransaction {
+		return c.copyIn(ctx, sqlStr, next)
+	}
+	if _, err := c.conn.ExecContext(ctx, "BEGIN"); err != nil {
+		return 0, err
+	}
+	n, err := c.copyIn(ctx, sqlStr, next)
+	if err != nil {
+		c.conn.ExecContext(context.Background(), "ROLLBACK")
+		return 0, err
+	}
+	if _, err := c.conn.ExecContext(ctx, "COMMIT"); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// copyDataWriter lib/pq 的 COPY FROM STDIN 语句，CopyData 发送一行原始数据
+type copyDataWriter interface {
+	CopyData(ctx context.Context, line string) (driver.Result, error)
+}
+
+// copyIn 在会话连接上执行 COPY FROM STDIN 并逐行发送 next 返回的数据，直到 io.EOF，返回导入的行数
+func (c *CLI) copyIn(ctx context.Context, sqlStr string, next func() (string, error)) (int64, error) {
+	var n int64
+	err := c.conn.Raw(func(driverConn interface{}) error {
+		conn, ok := driverConn.(driver.Conn)
+		if !ok {
+			return errors.New("\\copy: driver connection does not support COPY")
+		}
+		stmt, err := conn.Prepare(sqlStr)
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+		w, ok := stmt.(copyDataWriter)
+		if !ok {
+			return errors.New("\\copy: driver does not support COPY FROM STDIN")
+		}
+
+		for {
+			line, err := next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return err
+			}
+			if _, err := w.CopyData(ctx, line); err != nil {
+				return err
+			}
+		}
+		// 不带参数的 Exec 结束 COPY 并返回服务器的结果
+		result, err := stmt.Exec(nil)
+		if err != nil {
+			return err
+		}
+		n, _ = result.RowsAffected()
+		return nil
+	})
+	return n, err
+}
+
+// copyOptions \copy ... TO 的输出选项
+type copyOptions struct {
+	format        string   // text 或 csv
+	header        bool     // 首行输出列名
+	delimiter     string   // 字段分隔符，text 默认为制表符，csv 默认为逗号
+	null          string   // NULL 的表示，text 默认为 \N，csv 默认为空串
+	quote         string   // csv 的引号字符，默认为 "
+	escape        string   // csv 中引号字符前的转义字符，默认与 quote 相同
+	forceQuote    []string // csv 中总是加引号的列
+	forceQuoteAll bool     // FORCE_QUOTE *
+	encoding      string   // 输出编码，只支持 UTF8
+}
+
+// copyFromOnlyOptions 只能用于 COPY FROM 的选项
+var copyFromOnlyOptions = map[string]bool{
+	"force_not_null": true, "force_null": true, "freeze": true, "default": true,
+	"on_error": true, "log_verbosity": true,
+}
+
+// parseCopyOptions 解析 \copy ... TO 的选项，支持 WITH (name value, ...) 与旧语法
+// [WITH] [BINARY] [DELIMITER [AS] 'c'] [NULL [AS] 'str'] [CSV [HEADER] [QUOTE [AS] 'q'] [ESCAPE [AS] 'e'] [FORCE QUOTE {col, ... | *}]]
+// 选项的合法性检查与服务器的 COPY TO 一致
+func parseCopyOptions(s string) (copyOptions, error) {
+	opts := copyOptions{format: "text"}
+	tokens, err := copyOptionTokens(s)
+	if err != nil {
+		return opts, err
+	}
+	if len(tokens) > 0 && strings.EqualFold(tokens[0], "with") {
+		tokens = tokens[1:]
+	}
+
+	var delimiter, null, quote, escape *string
+	set := func(name string, target **string, value string) error {
+		if *target != nil {
+			return fmt.Errorf("conflicting or redundant options: %s", name)
+		}
+		v := literalValue(value)
+		*target = &v
+		return nil
+	}
+
+	if len(tokens) > 0 && tokens[0] == "(" {
+		// WITH (FORMAT csv, HEADER, DELIMITER ';', FORCE_QUOTE (a, b), ...)
+		i := 1
+		for i < len(tokens) && tokens[i] != ")" {
+			name := strings.ToLower(tokens[i])
+			i++
+			var values []string
+			switch {
+			case i < len(tokens) && tokens[i] == "(":
+				for i++; i < len(tokens) && tokens[i] != ")"; i++ {
+					if tokens[i] != "," {
+						values = append(values, tokens[i])
+					}
+				}
+				i++
+			case i < len(tokens) && tokens[i] != "," && tokens[i] != ")":
+				values = append(values, tokens[i])
+				i++
+			}
+			if i < len(tokens) && tokens[i] == "," {
+				i++
+			}
+			value := ""
+			if len(values) > 0 {
+				value = values[0]
+			}
+
+			switch name {
+			case "format":
+				opts.format = strings.ToLower(literalValue(value))
+			case "header":
+				switch strings.ToLower(literalValue(value)) {
+				case "", "true", "on", "1":
+					opts.header = true
+				case "false", "off", "0":
+					opts.header = false
+				case "match":
+					return opts, fmt.Errorf("cannot use \"match\" with HEADER in COPY TO")
+				default:
+					return opts, fmt.Errorf("header requires a Boolean value or \"match\"")
+				}
+			case "delimiter":
+				err = set(name, &delimiter, value)
+			case "null":
+				err = set(name, &null, value)
+			case "quote":
+				err = set(name, &quote, value)
+			case "escape":
+				err = set(name, &escape, value)
+			case "encoding":
+				opts.encoding = literalValue(value)
+			case "force_quote":
+				if value == "*" {
+					opts.forceQuoteAll = true
+				} else {
+					for _, v := range values {
+						opts.forceQuote = append(opts.forceQuote, identifierValue(v))
+					}
+				}
+			default:
+				if copyFromOnlyOptions[name] {
+					return opts, fmt.Errorf("COPY %s cannot be used with COPY TO", strings.ToUpper(name))
+				}
+				return opts, fmt.Errorf("option \"%s\" not recognized", name)
+			}
+			if err != nil {
+				return opts, err
+			}
+		}
+		if i >= len(tokens) {
+			return opts, copyParseError("")
+		}
+		if i+1 < len(tokens) {
+			return opts, copyParseError(strings.Join(tokens[i+1:], " "))
+		}
+	} else {
+		for i := 0; i < len(tokens); i++ {
+			word := strings.ToLower(tokens[i])
+			// 旧语法中 DELIMITER、NULL、QUOTE、ESCAPE 后可以跟 AS
+			value := func() string {
+				if i+1 < len(tokens) && strings.EqualFold(tokens[i+1], "as") {
+					i++
+				}
+				if i+1 >= len(tokens) {
+					return ""
+				}
+				i++
+				return tokens[i]
+			}
+			switch word {
+			case "binary":
+				opts.format = "binary"
+			case "csv":
+				opts.format = "csv"
+			case "header":
+				opts.header = true
+			case "delimiter", "null", "quote", "escape":
+				v := value()
+				if !strings.HasPrefix(v, "'") {
+					return opts, copyParseError(strings.Join(tokens[i:], " "))
+				}
+				target := map[string]**string{"delimiter": &delimiter, "null": &null, "quote": &quote, "escape": &escape}[word]
+				if err := set(word, target, v); err != nil {
+					return opts, err
+				}
+			case "encoding":
+				opts.encoding = literalValue(value())
+			case "force":
+				if i+1 < len(tokens) && strings.EqualFold(tokens[i+1], "not") {
+					return opts, fmt.Errorf("COPY FORCE_NOT_NULL cannot be used with COPY TO")
+				}
+				if i+1 >= len(tokens) || !strings.EqualFold(tokens[i+1], "quote") {
+					return opts, copyParseError(strings.Join(tokens[i:], " "))
+				}
+				i++
+				// 逗号分隔的列名或 *
+				for i+1 < len(tokens) {
+					i++
+					if tokens[i] == "*" {
+						opts.forceQuoteAll = true
+					} else {
+						opts.forceQuote = append(opts.forceQuote, identifierValue(tokens[i]))
+					}
+					if i+1 >= len(tokens) || tokens[i+1] != "," {
+						break
+					}
+					i++
+				}
+			default:
+				return opts, copyParseError(strings.Join(tokens[i:], " "))
+			}
+		}
+	}
+
+	return opts, opts.resolve(delimiter, null, quote, escape)
+}
+
+// resolve 按格式填入默认值并检查选项组合
+func (o *copyOptions) resolve(delimiter, null, quote, escape *string) error {
+	switch o.format {
+	case "text", "csv":
+	case "binary":
+		return fmt.Errorf("\\copy: binary format is not supported for TO")
+	default:
+		return fmt.Errorf("COPY format \"%s\" not recognized", o.format)
+	}
+	csv := o.format == "csv"
+
+	o.delimiter, o.null = "\t", `\N`
+	if csv {
+		o.delimiter, o.null = ",", ""
+	}
+	if delimiter != nil {
+		o.delimiter = *delimiter
+	}
+	if null != nil {
+		o.null = *null
+	}
+	if len(o.delimiter) != 1 || o.delimiter == "\n" || o.delimiter == "\r" {
+		return fmt.Errorf("COPY delimiter must be a single one-byte character")
+	}
+
+	if !csv {
+		switch {
+		case quote != nil:
+			return fmt.Errorf("COPY quote available only in CSV mode")
+		case escape != nil:
+			return fmt.Errorf("COPY escape available only in CSV mode")
+		case o.forceQuoteAll || len(o.forceQuote) > 0:
+			return fmt.Errorf("COPY force quote available only in CSV mode")
+		}
+	}
+	o.quote = "\""
+	if quote != nil {
+		o.quote = *quote
+	}
+	o.escape = o.quote
+	if escape != nil {
+		o.escape = *escape
+	}
+	if csv {
+		if len(o.quote) != 1 {
+			return fmt.Errorf("COPY quote must be a single one-byte character")
+		}
+		if len(o.escape) != 1 {
+			return fmt.Errorf("COPY escape must be a single one-byte character")
+		}
+		if o.delimiter == o.quote {
+			return fmt.Errorf("COPY delimiter and quote must be different")
+		}
+	}
+
+	// 结果以 UTF-8 返回，客户端不做编码转换
+	switch strings.ToUpper(strings.ReplaceAll(o.encoding, "-", "")) {
+	case "", "UTF8", "UNICODE":
+	default:
+		return fmt.Errorf("\\copy: ENCODING %s is not supported for TO (output is always UTF8)", o.encoding)
+	}
+	return nil
+}
+
+// copyOptionTokens 将选项拆分为单词、字符串字面量（保留开头的单引号以便区分）、双引号标识符、括号与逗号
+// E'...' 字符串中的反斜杠转义（\t、\n 等）在此处理
+func copyOptionTokens(s string) ([]string, error) {
+	var tokens []string
+	for i := 0; i < len(s); {
+		ch := s[i]
+		switch {
+		case ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r':
+			i++
+		case ch == '(' || ch == ')' || ch == ',':
+			tokens = append(tokens, string(ch))
+			i++
+		case ch == '\'' || (ch == 'E' || ch == 'e') && i+1 < len(s) && s[i+1] == '\'':
+			escapes := ch != '\''
+			if escapes {
+				i++
+			}
+			var sb strings.Builder
+			sb.WriteByte('\'')
+			j := i + 1
+			for ; j < len(s); j++ {
+				if escapes && s[j] == '\\' && j+1 < len(s) {
+					j++
+					sb.WriteString(unescapeChar(s[j]))
+					continue
+				}
+				if s[j] == '\'' {
+					if j+1 < len(s) && s[j+1] == '\'' {
+						sb.WriteByte('\'')
+						j++
+						continue
+					}
+					break
+				}
+				sb.WriteByte(s[j])
+			}
+			if j >= len(s) {
+				return nil, fmt.Errorf("\\copy: unterminated quoted string")
+			}
+			tokens = append(tokens, sb.String())
+			i = j + 1
+		case ch == '"':
+			end := strings.IndexByte(s[i+1:], '"')
+			if end < 0 {
+				return nil, fmt.Errorf("\\copy: unterminated quoted identifier")
+			}
+			tokens = append(tokens, s[i:i+end+2])
+			i += end + 2
+		default:
+			j := i
+			for j < len(s) && !strings.ContainsRune(" \t\n\r(),'\"", rune(s[j])) {
+				j++
+			}
+			tokens = append(tokens, s[i:j])
+			i = j
+		}
+	}
+	return tokens, nil
+}
+
+// unescapeChar 返回 E'...' 字符串中反斜杠转义对应的字符
+func unescapeChar(ch byte) string {
+	switch ch {
+	case 't':
+		return "\t"
+	case 'n':
+		return "\n"
+	case 'r':
+		return "\r"
+	case 'b':
+		return "\b"
+	case 'f':
+		return "\f"
+	}
+	return string(ch)
+}
+
+// literalValue 返回选项值：字符串字面量去掉开头的引号标记，双引号标识符去掉引号，其余原样
+func literalValue(token string) string {
+	if strings.HasPrefix(token, "'") {
+		return token[1:]
+	}
+	return strings.Trim(token, "\"")
+}
+
+// identifierValue 返回列名：加双引号的名称去掉引号并还原其中重复的双引号，未加双引号的名称转为小写
+func identifierValue(token string) string {
+	if strings.HasPrefix(token, "\"") {
+		return strings.ReplaceAll(strings.Trim(token, "\""), "\"\"", "\"")
+	}
+	return strings.ToLower(token)
+}
+
+// copyTo 执行 \copy ... TO：lib/pq 不支持 COPY TO STDOUT，因此执行等价的查询并按 COPY 的 text/csv 格式在客户端输出
+func (c *CLI) copyTo(cmd copyCommand) (int64, error) {
+	opts, err := parseCopyOptions(cmd.options)
+	if err != nil {
+		return 0, err
+	}
+
+	query := strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(cmd.source, "("), ")"))
+	if !strings.HasPrefix(cmd.source, "(") {
+		cols := "*"
+		if cmd.columns != "" {
+			cols = strings.TrimSuffix(strings.TrimPrefix(cmd.columns, "("), ")")
+		}
+		// 与 COPY table TO 一致，不包括继承的子表
+		query = fmt.Sprintf("SELECT %s FROM ONLY %s", cols, cmd.source)
+	}
+	if err := c.checkReadOnly(query); err != nil {
+		return 0, err
+	}
+
+	var out io.Writer = c.out
+	switch {
+	case cmd.stdio == "pstdout":
+		out = c.term
+	case cmd.file != "":
+		f, err := os.Create(cmd.file)
+		if err != nil {
+			return 0, fmt.Errorf("%s: %w", cmd.file, err)
+		}
+		defer f.Close()
+		out = f
+	}
+
+	ctx, stop := interruptContext(context.Background())
+	defer stop()
+	rows, err := c.conn.QueryContext(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	cols, _ := rows.Columns()
+	colTypes, _ := rows.ColumnTypes()
+	force := make([]bool, len(cols))
+	for _, name := range opts.forceQuote {
+		i := indexOf(cols, name)
+		if i < 0 {
+			return 0, fmt.Errorf("FORCE_QUOTE column \"%s\" not referenced by COPY", name)
+		}
+		force[i] = true
+	}
+
+	w := bufio.NewWriter(out)
+	fields := make([]string, len(cols))
+	if opts.header {
+		for i, name := range cols {
+			fields[i] = opts.field(name, false)
+		}
+		w.WriteString(strings.Join(fields, opts.delimiter) + "\n")
+	}
+
+	var n int64
+	for rows.Next() {
+		vals := make([]interface{}, len(cols))
+		valPtrs := make([]interface{}, len(cols))
+		for i := range vals {
+			valPtrs[i] = &vals[i]
+		}
+		if err := rows.Scan(valPtrs...); err != nil {
+			return n, err
+		}
+		for i, v := range vals {
+			if v == nil {
+				fields[i] = opts.null
+				continue
+			}
+			var colType *sql.ColumnType
+			if i < len(colTypes) {
+				colType = colTypes[i]
+			}
+			fields[i] = opts.field(copyText(v, colType), opts.forceQuoteAll || force[i])
+		}
+		w.WriteString(strings.Join(fields, opts.delimiter) + "\n")
+		n++
+	}
+	if err := rows.Err(); err != nil {
+		return n, err
+	}
+	return n, w.Flush()
+}
+
+// indexOf 返回 name 在列表中第一次出现的位置，不存在时返回 -1
+func indexOf(list []string, name string) int {
+	for i, s := range list {
+		if s == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// field 按 COPY 的格式输出一个非 NULL 字段
+// text：反斜杠、控制字符与分隔符以反斜杠转义；csv：包含分隔符、引号、换行或与 NULL 表示相同时加引号
+func (o copyOptions) field(s string, forceQuote bool) string {
+	var sb strings.Builder
+	if o.format == "text" {
+		for i := 0; i < len(s); i++ {
+			switch ch := s[i]; ch {
+			case '\\':
+				sb.WriteString(`\\`)
+			case '\b':
+				sb.WriteString(`\b`)
+			case '\f':
+				sb.WriteString(`\f`)
+			case '\n':
+				sb.WriteString(`\n`)
+			case '\r':
+				sb.WriteString(`\r`)
+			case '\t':
+				sb.WriteString(`\t`)
+			case '\v':
+				sb.WriteString(`\v`)
+			default:
+				if ch == o.delimiter[0] {
+					sb.WriteByte('\\')
+				}
+				sb.WriteByte(ch)
+			}
+		}
+		return sb.String()
+	}
+
+	if !forceQuote && s != o.null && s != `\.` && !strings.ContainsAny(s, o.delimiter+o.quote+"\r\n") {
+		return s
+	}
+	sb.WriteString(o.quote)
+	for i := 0; i < len(s); i++ {
+		if s[i] == o.quote[0] || s[i] == o.escape[0] {
+			sb.WriteByte(o.escape[0])
+		}
+		sb.WriteByte(s[i])
+	}
+	sb.WriteString(o.quote)
+	return sb.String()
+}
+
+// copyText 以服务器的文本格式输出值，不受 \pset 显示选项影响
+func copyText(v interface{}, colType *sql.ColumnType) string {
+	typeName := ""
+	if colType != nil {
+		typeName = colType.DatabaseTypeName()
+	}
+	switch val := v.(type) {
+	case []byte:
+		if typeName == "BYTEA" {
+			return "\\x" + hex.EncodeToString(val)
+		}
+		return string(val)
+	case time.Time:
+		return postgresTime(val, typeName)
+	case bool:
+		if val {
+			return "t"
+		}
+		return "f"
+	case int64:
+		return strconv.FormatInt(val, 10)
+	case float64:
+		return postgresFloat(val)
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+// postgresFloat 以 Postgres（12 及以上）的格式输出浮点数：最短精确表示，指数小于 -4 或不小于 15 时使用科学计数法
+func postgresFloat(f float64) string {
+	switch {
+	case math.IsNaN(f):
+		return "NaN"
+	case math.IsInf(f, 1):
+		return "Infinity"
+	case math.IsInf(f, -1):
+		return "-Infinity"
+	}
+	e := strconv.FormatFloat(f, 'e', -1, 64)
+	if exp, _ := strconv.Atoi(e[strings.IndexByte(e, 'e')+1:]); exp < -4 || exp >= 15 {
+		return e
+	}
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
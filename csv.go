@@ -0,0 +1,72 @@
+package postgres
+
+import (
+	"database/sql"
+	"io"
+	"strings"
+)
+
+// utf8BOM UTF-8 字节顺序标记，Windows 上的 Excel 依据它识别 UTF-8 编码的 CSV
+const utf8BOM = "\xef\xbb\xbf"
+
+// displayCSV 以 CSV 格式（RFC 4180）输出结果，首行为列名（\pset tuples_only 时省略），返回行数
+// CSV 用于导出，不受 maxrows 限制，也不输出标题与行数等页脚
+func (c *CLI) displayCSV(rows *sql.Rows, cols []string, colTypes []*sql.ColumnType) int {
+	sep := c.settings.CSVFieldSep
+	if c.settings.CSVBOM {
+		c.writeBOM()
+	}
+
+	if !c.settings.TuplesOnly {
+		header := make([]string, len(cols))
+		for i, col := range cols {
+			header[i] = csvField(col, sep)
+		}
+		io.WriteString(c.out, strings.Join(header, sep)+"\n")
+	}
+
+	rowCount := 0
+	for rows.Next() {
+		rowCount++
+		vals := make([]interface{}, len(cols))
+		valPtrs := make([]interface{}, len(cols))
+		for i := range vals {
+			valPtrs[i] = &vals[i]
+		}
+		rows.Scan(valPtrs...)
+
+		fields := make([]string, len(vals))
+		for i, v := range vals {
+			var colType *sql.ColumnType
+			if i < len(colTypes) {
+				colType = colTypes[i]
+			}
+			// 与 psql 一致，NULL 输出为不加引号的 \pset null 文本（默认空字段），空串输出为 ""
+			if v == nil {
+				fields[i] = c.settings.Null
+				continue
+			}
+			fields[i] = csvField(c.formatValue(v, colType), sep)
+		}
+		io.WriteString(c.out, strings.Join(fields, sep)+"\n")
+	}
+	return rowCount
+}
+
+// csvField 按需为 CSV 字段加引号：包含分隔符、引号、换行或为空串时以双引号括起，引号写作 ""
+func csvField(s, sep string) string {
+	if s != "" && !strings.ContainsAny(s, sep+"\"\r\n") {
+		return s
+	}
+	return "\"" + strings.ReplaceAll(s, "\"", "\"\"") + "\""
+}
+
+// writeBOM 在 \o 打开的空文件开头写入 UTF-8 BOM；输出到终端或文件已有内容时不写入
+func (c *CLI) writeBOM() {
+	if c.outFile == nil {
+		return
+	}
+	if info, err := c.outFile.Stat(); err == nil && info.Size() == 0 {
+		io.WriteString(c.outFile, utf8BOM)
+	}
+}
@@ -0,0 +1,104 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// describePartitions 输出分区信息：分区表显示分区键与分区列表，分区显示其父表与分区范围
+// 声明式分区需要 PostgreSQL 10 及以上，旧版本不输出
+func (c *CLI) describePartitions(tableName string) {
+	if !c.serverInfo.AtLeast(10, 0) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var oid uint32
+	var relkind string
+	var isPartition bool
+	err := c.conn.QueryRowContext(ctx, `
+		SELECT c.oid, c.relkind, c.relispartition
+		FROM pg_catalog.pg_class c
+		LEFT JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		WHERE c.relname = $1 AND n.nspname = 'public'
+	`, tableName).Scan(&oid, &relkind, &isPartition)
+	if err != nil {
+		return
+	}
+
+	if isPartition {
+		var parent, bound string
+		err := c.conn.QueryRowContext(ctx, `
+			SELECT i.inhparent::pg_catalog.regclass::text, pg_catalog.pg_get_expr(c.relpartbound, c.oid)
+			FROM pg_catalog.pg_inherits i
+			JOIN pg_catalog.pg_class c ON c.oid = i.inhrelid
+			WHERE c.oid = $1
+		`, oid).Scan(&parent, &bound)
+		if err == nil {
+			fmt.Fprintf(c.term, "Partition of: %s %s\n", parent, bound)
+		}
+	}
+
+	if relkind != "p" {
+		return
+	}
+
+	var partKey string
+	if err := c.conn.QueryRowContext(ctx, "SELECT pg_catalog.pg_get_partkeydef($1)", oid).Scan(&partKey); err == nil {
+		fmt.Fprintf(c.term, "Partition key: %s\n", partKey)
+	}
+
+	rows, err := c.conn.QueryContext(ctx, `
+		SELECT c.oid::pg_catalog.regclass::text, pg_catalog.pg_get_expr(c.relpartbound, c.oid), c.relkind = 'p'
+		FROM pg_catalog.pg_inherits i
+		JOIN pg_catalog.pg_class c ON c.oid = i.inhrelid
+		WHERE i.inhparent = $1
+		ORDER BY pg_catalog.pg_get_expr(c.relpartbound, c.oid) = 'DEFAULT', c.oid::pg_catalog.regclass::text
+	`, oid)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	var partitions []string
+	for rows.Next() {
+		var name, bound string
+		var partitioned bool
+		if err := rows.Scan(&name, &bound, &partitioned); err != nil {
+			return
+		}
+		line := name + " " + bound
+		if partitioned {
+			line += ", PARTITIONED"
+		}
+		partitions = append(partitions, line)
+	}
+
+	if len(partitions) == 0 {
+		fmt.Fprintf(c.term, "Number of partitions: 0\n")
+		return
+	}
+	fmt.Fprintf(c.term, "Partitions: %s\n", strings.Join(partitions, ",\n            "))
+}
+
+// describeComment 输出表的注释（\d+），没有注释时不输出
+func (c *CLI) describeComment(tableName string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	var comment sql.NullString
+	err := c.conn.QueryRowContext(ctx, `
+		SELECT pg_catalog.obj_description(c.oid, 'pg_class')
+		FROM pg_catalog.pg_class c
+		LEFT JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+		WHERE c.relname = $1 AND n.nspname = 'public'
+	`, tableName).Scan(&comment)
+	if err == nil && comment.Valid {
+		fmt.Fprintf(c.term, "Description: %s\n", comment.String)
+	}
+}
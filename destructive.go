@@ -0,0 +1,53 @@
+package postgres
+
+import (
+	"fmt"
+	"strings"
+)
+
+// destructiveStatement 判断语句是否具有破坏性，返回描述
+// DROP、TRUNCATE、ALTER ... DROP，以及顶层没有 WHERE 的 DELETE/UPDATE（包括数据修改型 CTE 的主语句）
+func destructiveStatement(sqlStr string) (string, bool) {
+	words := topLevelWords(stripLeadingComments(sqlStr))
+	if len(words) == 0 {
+		return "", false
+	}
+
+	keyword := words[0]
+	if keyword == "WITH" {
+		keyword = cteMainKeyword(words[1:])
+	}
+
+	switch keyword {
+	case "DROP", "TRUNCATE":
+		return keyword, true
+	case "ALTER":
+		return "ALTER ... DROP", containsWord(words, "DROP")
+	case "DELETE", "UPDATE":
+		return keyword + " without WHERE", !containsWord(words, "WHERE")
+	}
+	return keyword, false
+}
+
+// confirmDestructive 开启 ConfirmDestructive 时，交互模式下执行破坏性语句前请求确认
+// 返回 false 表示用户取消；非交互模式下不提示
+func (c *CLI) confirmDestructive(sqlStr string) bool {
+	if !c.config.ConfirmDestructive || !c.reader.Interactive() {
+		return true
+	}
+	kind, ok := destructiveStatement(sqlStr)
+	if !ok {
+		return true
+	}
+
+	c.reader.SetPrompt(fmt.Sprintf("%s: Are you sure? (y/N) ", kind))
+	answer, err := c.reader.ReadLine()
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	}
+	return false
+}
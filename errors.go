@@ -0,0 +1,128 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"syscall"
+
+	"github.com/lib/pq"
+)
+
+// 连接失败的分类错误，Connect 与 \c 返回的错误可用 errors.Is 判断
+var (
+	// ErrConnectionRefused 服务器拒绝连接：未运行或未在该主机、端口上监听
+	ErrConnectionRefused = errors.New("connection refused")
+	// ErrAuthFailed 认证失败：密码错误（28P01）或 pg_hba.conf 拒绝、角色不存在等（28000）
+	ErrAuthFailed = errors.New("authentication failed")
+	// ErrDatabaseNotFound 要连接的数据库不存在（3D000）
+	ErrDatabaseNotFound = errors.New("database does not exist")
+	// ErrTimeout 在 ConnectTimeout 内未能建立连接
+	ErrTimeout = errors.New("connection timed out")
+)
+
+// showErrorVerbose 以最详细的形式重新显示最近一次错误（\errverbose）
+func (c *CLI) showErrorVerbose() {
+	if c.lastErr == nil {
+		fmt.Fprintf(c.term, "There is no previous error.\n")
+		return
+	}
+
+	var pqErr *pq.Error
+	if !errors.As(c.lastErr, &pqErr) {
+		fmt.Fprintf(c.term, "ERROR:  %v\n", c.lastErr)
+		return
+	}
+
+	severity := pqErr.Severity
+	if severity == "" {
+		severity = "ERROR"
+	}
+	fmt.Fprintf(c.term, "%s:  %s: %s\n", severity, pqErr.Code, pqErr.Message)
+
+	fields := []struct {
+		label string
+		value string
+	}{
+		{"DETAIL", pqErr.Detail},
+		{"HINT", pqErr.Hint},
+		{"QUERY", pqErr.InternalQuery},
+		{"CONTEXT", pqErr.Where},
+		{"POSITION", pqErr.Position},
+		{"INTERNAL POSITION", pqErr.InternalPosition},
+		{"SCHEMA NAME", pqErr.Schema},
+		{"TABLE NAME", pqErr.Table},
+		{"COLUMN NAME", pqErr.Column},
+		{"DATATYPE NAME", pqErr.DataTypeName},
+		{"CONSTRAINT NAME", pqErr.Constraint},
+	}
+	for _, f := range fields {
+		if f.value != "" {
+			fmt.Fprintf(c.term, "%s:  %s\n", f.label, f.value)
+		}
+	}
+	if pqErr.Routine != "" || pqErr.File != "" {
+		fmt.Fprintf(c.term, "LOCATION:  %s, %s:%s\n", pqErr.Routine, pqErr.File, pqErr.Line)
+	}
+	fmt.Fprintf(c.term, "\n")
+}
+
+// printHint 输出服务器错误的 HINT；服务器没有给出提示而 SQLSTATE 属于 codes 时输出 fallback
+func (c *CLI) printHint(err error, fallback string, codes ...string) {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return
+	}
+	hint := pqErr.Hint
+	if hint == "" && slices.Contains(codes, string(pqErr.Code)) {
+		hint = fallback
+	}
+	if hint != "" {
+		fmt.Fprintf(c.term, "%s\n", c.style(ansiYellow, "HINT:  "+hint))
+	}
+}
+
+// connectError 分类后的连接错误：Error 返回去除驱动前缀并附带提示的说明，
+// errors.Is 匹配分类错误；err 为隐藏了密码的错误，其链中仍有原始错误，errors.As 可以取得 *pq.Error
+type connectError struct {
+	kind error
+	msg  string
+	err  error
+}
+
+func (e *connectError) Error() string { return e.msg }
+
+func (e *connectError) Unwrap() []error { return []error{e.kind, e.err} }
+
+// classifyConnectError 将连接错误归类为 ErrConnectionRefused、ErrAuthFailed、ErrDatabaseNotFound 或 ErrTimeout：
+// 服务器返回的错误按 SQLSTATE，网络错误按系统错误码与超时判断；无法归类的错误只去除其中的密码后返回
+func (c *CLI) classifyConnectError(err error) error {
+	var kind error
+	var hint string
+	var pqErr *pq.Error
+	var netErr net.Error
+	switch {
+	case errors.As(err, &pqErr):
+		switch pqErr.Code {
+		case "28P01":
+			kind, hint = ErrAuthFailed, "check the user name and password"
+		case "28000":
+			kind, hint = ErrAuthFailed, "check the user name and the server's pg_hba.conf"
+		case "3D000":
+			kind, hint = ErrDatabaseNotFound, "check the database name, or connect to \"postgres\" and list databases with \\l"
+		}
+	case errors.Is(err, syscall.ECONNREFUSED):
+		kind, hint = ErrConnectionRefused, "is the server running and accepting connections on that host and port?"
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		kind, hint = ErrTimeout, "the server did not respond within the connect timeout"
+	}
+	if kind == nil {
+		return redactError(err)
+	}
+	redactedErr := redactError(err)
+	msg := strings.Replace(redactedErr.Error(), "pq: ", "", 1)
+	return &connectError{kind: kind, msg: msg + " (" + hint + ")", err: redactedErr}
+}
@@ -0,0 +1,48 @@
+package postgres
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/lib/pq"
+)
+
+func TestClassifyConnectError(t *testing.T) {
+	c := &CLI{}
+	tests := []struct {
+		name string
+		err  error
+		kind error
+		code pq.ErrorCode
+	}{
+		{"auth failed", &pq.Error{Code: "28P01", Message: "password authentication failed for user \"app\""}, ErrAuthFailed, "28P01"},
+		{"database not found", &pq.Error{Code: "3D000", Message: "database \"x\" does not exist"}, ErrDatabaseNotFound, "3D000"},
+		{"refused", fmt.Errorf("dial tcp host=db password=secret: %w", syscall.ECONNREFUSED), ErrConnectionRefused, ""},
+		{"unclassified server error", fmt.Errorf("connect password=secret: %w", &pq.Error{Code: "53300", Message: "too many connections"}), nil, "53300"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := c.classifyConnectError(tt.err)
+			if tt.kind != nil && !errors.Is(err, tt.kind) {
+				t.Errorf("errors.Is(%v, %v) = false", err, tt.kind)
+			}
+			if tt.code != "" {
+				var pqErr *pq.Error
+				if !errors.As(err, &pqErr) || pqErr.Code != tt.code {
+					t.Errorf("errors.As(%v) did not find *pq.Error with code %s", err, tt.code)
+				}
+			}
+			if strings.Contains(err.Error(), "secret") {
+				t.Errorf("error %q contains the password", err.Error())
+			}
+			// 分类错误包装的是隐藏了密码的错误
+			var ce *connectError
+			if errors.As(err, &ce) && strings.Contains(ce.err.Error(), "secret") {
+				t.Errorf("wrapped error %q contains the password", ce.err.Error())
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WarnExpensive 的默认阈值
+const (
+	defaultWarnCost = 1e7     // 估算的总代价
+	defaultWarnRows = 1000000 // 估算的结果行数
+)
+
+// explainableStatement 判断语句能否用 EXPLAIN 估算代价：查询、数据修改语句与 EXECUTE
+func explainableStatement(sqlStr string) bool {
+	words := topLevelWords(stripLeadingComments(sqlStr))
+	if len(words) == 0 {
+		return false
+	}
+	keyword := words[0]
+	if keyword == "WITH" {
+		keyword = cteMainKeyword(words[1:])
+	}
+	switch keyword {
+	case "SELECT", "VALUES", "TABLE", "INSERT", "UPDATE", "DELETE", "MERGE", "EXECUTE":
+		return true
+	}
+	return false
+}
+
+// planEstimate 以 EXPLAIN（不执行语句）获取计划根节点估算的总代价与行数
+// 事务中以保存点包裹，EXPLAIN 失败不会使事务进入失败状态
+func (c *CLI) planEstimate(ctx context.Context, sqlStr string) (cost, rows float64, err error) {
+	if c.inTransaction {
+		if _, err := c.conn.ExecContext(ctx, "SAVEPOINT pgcli_explain"); err != nil {
+			return 0, 0, err
+		}
+		defer func() {
+			// ctx 可能已因 Ctrl-C 或超时取消，清理保存点使用独立的 context
+			cleanupCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			if err != nil {
+				c.conn.ExecContext(cleanupCtx, "ROLLBACK TO SAVEPOINT pgcli_explain")
+			}
+			c.conn.ExecContext(cleanupCtx, "RELEASE SAVEPOINT pgcli_explain")
+		}()
+	}
+
+	var out []byte
+	if err := c.conn.QueryRowContext(ctx, "EXPLAIN (FORMAT JSON) "+sqlStr, c.stmtParams...).Scan(&out); err != nil {
+		return 0, 0, err
+	}
+	var plans []struct {
+		Plan struct {
+			TotalCost float64 `json:"Total Cost"`
+			PlanRows  float64 `json:"Plan Rows"`
+		} `json:"Plan"`
+	}
+	if err := json.Unmarshal(out, &plans); err != nil {
+		return 0, 0, err
+	}
+	if len(plans) == 0 {
+		return 0, 0, fmt.Errorf("EXPLAIN returned no plan")
+	}
+	return plans[0].Plan.TotalCost, plans[0].Plan.PlanRows, nil
+}
+
+// confirmExpensive 开启 WarnExpensive 时，交互模式下先 EXPLAIN 用户输入的语句，估算代价或行数超过阈值时请求确认
+// 返回 false 表示用户取消或中断；元命令生成的查询（\dt、\preview 等）、\watch 重复执行、非交互模式、
+// 事务已失败或无法估算时照常执行（错误由语句本身报告）
+func (c *CLI) confirmExpensive(sqlStr string) bool {
+	if !c.config.WarnExpensive || !c.userSQL || c.watching || !c.reader.Interactive() || c.txnFailed || !explainableStatement(sqlStr) {
+		return true
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	ctx, stopInterrupt := interruptContext(ctx)
+	defer stopInterrupt()
+	cost, rows, err := c.planEstimate(ctx, sqlStr)
+	if ctx.Err() != nil {
+		// EXPLAIN 期间按下 Ctrl-C 同样取消语句
+		return false
+	}
+	if err != nil || (cost < c.config.WarnCost && rows < float64(c.config.WarnRows)) {
+		return true
+	}
+
+	c.reader.SetPrompt(fmt.Sprintf("Estimated %s rows / cost %s. Proceed? (y/N) ", humanCount(rows), strconv.FormatFloat(cost, 'g', 3, 64)))
+	answer, err := c.reader.ReadLine()
+	if err != nil {
+		return false
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	}
+	return false
+}
+
+// humanCount 以 K、M、G 为单位简写数量，如 5000000 -> 5M，1500 -> 1.5K
+func humanCount(n float64) string {
+	for _, unit := range []struct {
+		size   float64
+		suffix string
+	}{{1e9, "G"}, {1e6, "M"}, {1e3, "K"}} {
+		if n >= unit.size {
+			return strings.TrimSuffix(fmt.Sprintf("%.1f", n/unit.size), ".0") + unit.suffix
+		}
+	}
+	return strconv.FormatFloat(n, 'f', 0, 64)
+}
@@ -0,0 +1,91 @@
+package postgres
+
+import (
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// formatValue 将扫描得到的值转换为显示文本，NULL 显示为 \pset null 设置的文本（默认空串）
+func (c *CLI) formatValue(v interface{}, colType *sql.ColumnType) string {
+	if v == nil {
+		return c.settings.Null
+	}
+
+	switch val := v.(type) {
+	case []byte:
+		if colType != nil && colType.DatabaseTypeName() == "BYTEA" {
+			return c.formatBytea(val)
+		}
+		if c.settings.PrettyArrays && colType != nil {
+			return prettyValue(colType, string(val))
+		}
+		return string(val)
+	case time.Time:
+		return c.formatTime(val, colType)
+	case bool:
+		if val {
+			return "t"
+		}
+		return "f"
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// formatBytea 按 \pset bytea 显示二进制数据：hex 为 Postgres 的 \x 十六进制格式，length 只显示长度
+// 直接输出原始字节可能破坏终端显示
+func (c *CLI) formatBytea(b []byte) string {
+	if c.settings.ByteaLength {
+		return fmt.Sprintf("[%d bytes]", len(b))
+	}
+	return "\\x" + hex.EncodeToString(b)
+}
+
+// formatTime 按列类型以 Postgres 的文本格式显示时间，保留小数秒与时区偏移
+// 设置了 \pset timeformat 时，timestamp/timestamptz 使用该 Go 时间布局
+func (c *CLI) formatTime(t time.Time, colType *sql.ColumnType) string {
+	typeName := ""
+	if colType != nil {
+		typeName = colType.DatabaseTypeName()
+	}
+
+	switch typeName {
+	case "DATE", "TIME", "TIMETZ":
+	default:
+		if c.settings.TimeFormat != "" {
+			return t.Format(c.settings.TimeFormat)
+		}
+	}
+	return postgresTime(t, typeName)
+}
+
+// postgresTime 以 Postgres 的默认文本格式输出 typeName 类型的时间值
+func postgresTime(t time.Time, typeName string) string {
+	switch typeName {
+	case "DATE":
+		return t.Format("2006-01-02")
+	case "TIME":
+		return t.Format("15:04:05.999999")
+	case "TIMETZ":
+		return t.Format("15:04:05.999999") + tzOffset(t)
+	case "TIMESTAMP":
+		return t.Format("2006-01-02 15:04:05.999999")
+	}
+	return t.Format("2006-01-02 15:04:05.999999") + tzOffset(t)
+}
+
+// tzOffset 以 Postgres 的格式显示时区偏移，如 +08、-03:30
+func tzOffset(t time.Time) string {
+	_, offset := t.Zone()
+	sign := "+"
+	if offset < 0 {
+		sign, offset = "-", -offset
+	}
+	s := fmt.Sprintf("%s%02d", sign, offset/3600)
+	if m := offset % 3600 / 60; m != 0 {
+		s += fmt.Sprintf(":%02d", m)
+	}
+	return s
+}
@@ -0,0 +1,90 @@
+package postgres
+
+import (
+	"strings"
+)
+
+// 输入高亮使用的样式：关键字为粗体蓝色，字符串为绿色
+const (
+	highlightKeyword = "\x1b[1;34m"
+	highlightString  = "\x1b[32m"
+)
+
+// sqlKeywords 输入高亮识别的 SQL 关键字
+var sqlKeywords = map[string]bool{}
+
+func init() {
+	for _, kw := range strings.Fields(`
+		ALL ALTER ANALYZE AND ANY AS ASC BEGIN BETWEEN BY CASCADE CASE CAST CHECK COLUMN COMMIT
+		CONSTRAINT COPY CREATE CROSS DEFAULT DELETE DESC DISTINCT DO DROP ELSE END EXCEPT EXISTS
+		EXPLAIN FALSE FETCH FOR FOREIGN FROM FULL FUNCTION GRANT GROUP HAVING IF ILIKE IN INDEX
+		INNER INSERT INTERSECT INTO IS JOIN KEY LATERAL LEFT LIKE LIMIT NOT NULL OFFSET ON OR
+		ORDER OUTER OVER PARTITION PRIMARY REFERENCES RETURNING REVOKE RIGHT ROLLBACK SAVEPOINT
+		SCHEMA SELECT SEQUENCE SET SHOW TABLE THEN TO TRIGGER TRUE TRUNCATE UNION UNIQUE UPDATE
+		USING VACUUM VALUES VIEW WHEN WHERE WINDOW WITH`) {
+		sqlKeywords[kw] = true
+	}
+}
+
+// sqlPainter 为 readline 输入行着色（readline.Painter）
+// 只插入 ANSI 样式，不改变可见字符，readline 按原始缓冲区计算光标位置
+type sqlPainter struct{}
+
+// Paint 高亮关键字与单引号字符串；双引号标识符与注释保持原样
+func (sqlPainter) Paint(line []rune, _ int) []rune {
+	var sb strings.Builder
+	for i := 0; i < len(line); {
+		ch := line[i]
+		switch {
+		case ch == '\'':
+			end := i + 1
+			for end < len(line) {
+				if line[end] == '\'' {
+					if end+1 < len(line) && line[end+1] == '\'' {
+						end += 2
+						continue
+					}
+					end++
+					break
+				}
+				end++
+			}
+			sb.WriteString(highlightString + string(line[i:end]) + ansiReset)
+			i = end
+		case ch == '"':
+			end := i + 1
+			for end < len(line) && line[end] != '"' {
+				end++
+			}
+			if end < len(line) {
+				end++
+			}
+			sb.WriteString(string(line[i:end]))
+			i = end
+		case ch == '-' && i+1 < len(line) && line[i+1] == '-':
+			sb.WriteString(string(line[i:]))
+			i = len(line)
+		case isWordRune(ch):
+			end := i
+			for end < len(line) && isWordRune(line[end]) {
+				end++
+			}
+			word := string(line[i:end])
+			if sqlKeywords[strings.ToUpper(word)] {
+				sb.WriteString(highlightKeyword + word + ansiReset)
+			} else {
+				sb.WriteString(word)
+			}
+			i = end
+		default:
+			sb.WriteRune(ch)
+			i++
+		}
+	}
+	return []rune(sb.String())
+}
+
+// isWordRune 判断是否为标识符字符
+func isWordRune(r rune) bool {
+	return r == '_' || r == '$' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r > 0x7f
+}
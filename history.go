@@ -0,0 +1,41 @@
+package postgres
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// addHistory 记录本次会话中输入的命令
+func (c *CLI) addHistory(input string) {
+	if input == "" {
+		return
+	}
+	c.history = append(c.history, input)
+}
+
+// History 返回本次会话中输入的命令（按输入顺序）
+func (c *CLI) History() []string {
+	history := make([]string, len(c.history))
+	copy(history, c.history)
+	return history
+}
+
+// showHistory 处理 \s：无参数时显示历史，否则写入文件
+func (c *CLI) showHistory(filename string) {
+	content := strings.Join(c.history, "\n")
+	if content != "" {
+		content += "\n"
+	}
+
+	if filename == "" {
+		fmt.Fprint(c.term, content)
+		return
+	}
+
+	if err := os.WriteFile(filename, []byte(content), 0600); err != nil {
+		fmt.Fprintf(c.term, "could not save history to file \"%s\": %v\n", filename, err)
+		return
+	}
+	fmt.Fprintf(c.term, "Wrote history to file \"%s\".\n", filename)
+}
@@ -0,0 +1,32 @@
+package postgres
+
+import "time"
+
+// OnStatement 注册语句执行回调，用于指标、审计与遥测，无需解析输出；传入 nil 取消
+// 每条语句（REPL、RunCommand、RunFile、\i 与 \watch）执行后在执行语句的协程中同步调用，回调应尽快返回
+//
+//	sql   替换变量后的语句
+//	tag   命令标签（SELECT、INSERT、CREATE、BEGIN 等，不含行数），语句失败或未执行时为空
+//	rows  返回或影响的行数，未知时为 -1
+//	d     执行耗时（含结果的显示）
+//	err   语句的错误
+func (c *CLI) OnStatement(hook func(sql string, tag string, rows int64, d time.Duration, err error)) {
+	c.onStatement = hook
+}
+
+// queryTag 返回查询语句的命令标签：带 RETURNING 的数据修改语句为其关键字，SHOW 与 FETCH 保持原样，其余为 SELECT
+func queryTag(sqlStr string) string {
+	words := topLevelWords(stripLeadingComments(sqlStr))
+	if len(words) == 0 {
+		return ""
+	}
+	keyword := words[0]
+	if keyword == "WITH" {
+		keyword = cteMainKeyword(words[1:])
+	}
+	switch keyword {
+	case "INSERT", "UPDATE", "DELETE", "MERGE", "SHOW", "FETCH":
+		return keyword
+	}
+	return "SELECT"
+}
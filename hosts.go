@@ -0,0 +1,85 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// hostPort 候选服务器地址
+type hostPort struct {
+	host string
+	port int
+}
+
+// candidateHosts 返回按顺序尝试连接的服务器列表
+// 优先使用 Config.Hosts，否则拆分 Config.Host 中以逗号分隔的主机；条目可带 ":端口"，缺省使用 Config.Port
+func (c *CLI) candidateHosts() []hostPort {
+	entries := c.config.Hosts
+	if len(entries) == 0 {
+		entries = strings.Split(c.config.Host, ",")
+	}
+
+	var hosts []hostPort
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		hp := hostPort{host: entry, port: c.config.Port}
+		// 只有一个冒号时视为 host:port，避免误拆 IPv6 地址
+		if i := strings.LastIndex(entry, ":"); i > 0 && strings.Count(entry, ":") == 1 {
+			if port, err := strconv.Atoi(entry[i+1:]); err == nil {
+				hp.host, hp.port = entry[:i], port
+			}
+		}
+		hosts = append(hosts, hp)
+	}
+	if len(hosts) == 0 {
+		hosts = append(hosts, hostPort{host: c.config.Host, port: c.config.Port})
+	}
+	return hosts
+}
+
+// validTargetSessionAttrs 支持的 target_session_attrs 取值
+var validTargetSessionAttrs = map[string]bool{
+	"":           true,
+	"any":        true,
+	"read-write": true,
+	"read-only":  true,
+	"primary":    true,
+	"standby":    true,
+}
+
+// checkSessionAttrs 检查连接是否满足 target_session_attrs
+// lib/pq 不支持多主机与 target_session_attrs，因此在客户端逐个主机检查
+func checkSessionAttrs(ctx context.Context, conn *sql.Conn, attrs string) error {
+	switch attrs {
+	case "", "any":
+		return nil
+	case "read-write", "read-only":
+		var readOnly string
+		if err := conn.QueryRowContext(ctx, "SHOW transaction_read_only").Scan(&readOnly); err != nil {
+			return err
+		}
+		if (readOnly == "on") != (attrs == "read-only") {
+			return fmt.Errorf("session is not %s", attrs)
+		}
+	case "primary", "standby":
+		var inRecovery bool
+		if err := conn.QueryRowContext(ctx, "SELECT pg_catalog.pg_is_in_recovery()").Scan(&inRecovery); err != nil {
+			return err
+		}
+		if inRecovery && attrs == "primary" {
+			return fmt.Errorf("server is in hot standby mode")
+		}
+		if !inRecovery && attrs == "standby" {
+			return fmt.Errorf("server is not in hot standby mode")
+		}
+	default:
+		return fmt.Errorf("invalid target_session_attrs value: \"%s\"", attrs)
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// defaultInsertTable 未指定且无法从查询中识别目标表时 INSERT 使用的表名
+const defaultInsertTable = "table_name"
+
+// singleTableQuery 匹配只读取一张表的 SELECT ... FROM name 或 TABLE name，用于推断 INSERT 的目标表
+var singleTableQuery = regexp.MustCompile(`(?is)^\s*(?:select\s.*?\sfrom|table)\s+((?:"[^"]+"|[a-z_][a-z0-9_$]*)(?:\s*\.\s*(?:"[^"]+"|[a-z_][a-z0-9_$]*))?)\s*(?:(?:as\s+)?[a-z_][a-z0-9_]*)?\s*(?:(?:where|group|order|limit|offset|fetch|for)\b.*)?$`)
+
+// insertTable 返回 INSERT 的目标表：\pset format insert TABLE 指定的表，否则为查询读取的单张表
+func (c *CLI) insertTable(sqlStr string) string {
+	if c.settings.InsertTable != "" {
+		return c.settings.InsertTable
+	}
+	sqlStr = stripLeadingComments(sqlStr)
+	if !singleTableSource(sqlStr) {
+		return defaultInsertTable
+	}
+	if m := singleTableQuery.FindStringSubmatch(sqlStr); m != nil {
+		return m[1]
+	}
+	return defaultInsertTable
+}
+
+// singleTableSource 判断查询是否只从一张表读取：顶层出现 UNION、INTERSECT、EXCEPT 或 JOIN，
+// 或 FROM 子句中出现逗号或子查询时，无法可靠推断目标表，需要以 \pset format insert TABLE 指定
+func singleTableSource(sql string) bool {
+	s := &sqlScanner{sql: sql}
+	depth := 0
+	inFrom := false
+
+	for s.pos < len(sql) {
+		if s.skipQuoted() {
+			continue
+		}
+		ch := sql[s.pos]
+		switch {
+		case ch == '(':
+			if depth == 0 && inFrom {
+				return false
+			}
+			depth++
+		case ch == ')':
+			depth--
+		case ch == ',':
+			if depth == 0 && inFrom {
+				return false
+			}
+		case isIdentChar(ch):
+			end := s.pos
+			for end < len(sql) && (isIdentChar(sql[end]) || sql[end] == '$') {
+				end++
+			}
+			if depth == 0 {
+				switch strings.ToUpper(sql[s.pos:end]) {
+				case "UNION", "INTERSECT", "EXCEPT", "JOIN":
+					return false
+				case "FROM":
+					inFrom = true
+				case "WHERE", "GROUP", "HAVING", "WINDOW", "ORDER", "LIMIT", "OFFSET", "FETCH", "FOR":
+					inFrom = false
+				}
+			}
+			s.pos = end
+			continue
+		}
+		s.pos++
+	}
+	return true
+}
+
+// displayInsert 将每行输出为一条 INSERT 语句（\pset format insert），返回行数
+// 与 CSV 一样用于导出，不受 maxrows 限制，也不输出标题与行数
+func (c *CLI) displayInsert(rows *sql.Rows, cols []string, colTypes []*sql.ColumnType, sqlStr string) int {
+	names := make([]string, len(cols))
+	for i, col := range cols {
+		names[i] = pq.QuoteIdentifier(col)
+	}
+	prefix := fmt.Sprintf("INSERT INTO %s (%s) VALUES (", c.insertTable(sqlStr), strings.Join(names, ", "))
+
+	rowCount := 0
+	for rows.Next() {
+		rowCount++
+		vals := make([]interface{}, len(cols))
+		valPtrs := make([]interface{}, len(cols))
+		for i := range vals {
+			valPtrs[i] = &vals[i]
+		}
+		rows.Scan(valPtrs...)
+
+		literals := make([]string, len(vals))
+		for i, v := range vals {
+			var colType *sql.ColumnType
+			if i < len(colTypes) {
+				colType = colTypes[i]
+			}
+			literals[i] = sqlLiteral(v, colType)
+		}
+		io.WriteString(c.out, prefix+strings.Join(literals, ", ")+");\n")
+	}
+	return rowCount
+}
+
+// sqlLiteral 将列值写成可直接用于 INSERT 的 SQL 字面量：NULL、TRUE/FALSE、数值原样，
+// 其余（文本、时间、bytea、数组、json 等）以服务器的文本格式加引号，含反斜杠时使用 E 前缀的转义字符串
+func sqlLiteral(v interface{}, colType *sql.ColumnType) string {
+	switch val := v.(type) {
+	case nil:
+		return "NULL"
+	case bool:
+		if val {
+			return "TRUE"
+		}
+		return "FALSE"
+	case int64:
+		return strconv.FormatInt(val, 10)
+	case float64:
+		// NaN 与 Infinity 只能以字符串输入
+		if math.IsNaN(val) || math.IsInf(val, 0) {
+			return "'" + postgresFloat(val) + "'"
+		}
+		return postgresFloat(val)
+	case []byte:
+		if colType != nil && colType.DatabaseTypeName() == "NUMERIC" {
+			if _, err := strconv.ParseFloat(string(val), 64); err == nil && !strings.ContainsAny(string(val), "nN") {
+				return string(val)
+			}
+		}
+	}
+	return strings.TrimSpace(pq.QuoteLiteral(copyText(v, colType)))
+}
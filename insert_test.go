@@ -0,0 +1,35 @@
+package postgres
+
+import "testing"
+
+func TestInsertTable(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+		want string
+	}{
+		{"single table", "SELECT * FROM users WHERE id > 1", "users"},
+		{"table command", "TABLE users", "users"},
+		{"qualified name", "SELECT id FROM public.users ORDER BY id", "public.users"},
+		{"quoted name", `SELECT * FROM "My Table"`, `"My Table"`},
+		{"alias", "SELECT u.id FROM users u LIMIT 10", "users"},
+		{"function in select list", "SELECT lower(name), coalesce(a, b) FROM users", "users"},
+		{"subquery in where", "SELECT * FROM users WHERE id IN (SELECT user_id FROM orders)", "users"},
+		{"leading comment", "-- dump\nSELECT * FROM users", "users"},
+		{"union", "SELECT * FROM t UNION SELECT * FROM u", defaultInsertTable},
+		{"union all", "SELECT * FROM t WHERE a = 1 UNION ALL SELECT * FROM u", defaultInsertTable},
+		{"except", "SELECT id FROM t EXCEPT SELECT id FROM u", defaultInsertTable},
+		{"join", "SELECT * FROM t JOIN u ON t.id = u.id", defaultInsertTable},
+		{"comma", "SELECT * FROM t, u WHERE t.id = u.id", defaultInsertTable},
+		{"subquery in from", "SELECT * FROM (SELECT * FROM t) s", defaultInsertTable},
+		{"keyword in string ignored", "SELECT 'a, b UNION c' FROM users", "users"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &CLI{settings: DefaultSettings()}
+			if got := c.insertTable(tt.sql); got != tt.want {
+				t.Errorf("insertTable(%q) = %q, want %q", tt.sql, got, tt.want)
+			}
+		})
+	}
+}
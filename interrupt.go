@@ -0,0 +1,26 @@
+package postgres
+
+import (
+	"context"
+	"os"
+	"os/signal"
+)
+
+// interruptContext 返回在收到 Ctrl-C（SIGINT）时取消的子 context，以及停止监听的函数
+// 取消 context 会使 lib/pq 向服务器发送取消请求
+func interruptContext(parent context.Context) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(parent)
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt)
+	go func() {
+		select {
+		case <-sigs:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, func() {
+		signal.Stop(sigs)
+		cancel()
+	}
+}
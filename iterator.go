@@ -0,0 +1,86 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+)
+
+// RowIterator 逐行读取 QueryRows 的结果，行从连接上按需读取，不缓存整个结果集
+// 与 Query 一样使用会话连接：在 Close 之前不能在该 CLI 上执行其他语句
+type RowIterator struct {
+	rows   *sql.Rows
+	cols   []string
+	cancel context.CancelFunc
+	done   bool // 已读完全部行
+}
+
+// QueryRows 在会话连接上执行查询并返回流式的行迭代器，参数以 $1、$2 占位；未连接时会自动连接
+// ctx 取消或在读完之前调用 Close 时，服务器上的查询随之取消，未读取的行不再传输
+//
+//	it, err := cli.QueryRows(ctx, "SELECT id, payload FROM events")
+//	defer it.Close()
+//	for it.Next() {
+//		it.Scan(&id, &payload)
+//	}
+//	err = it.Err()
+func (c *CLI) QueryRows(ctx context.Context, query string, args ...interface{}) (*RowIterator, error) {
+	if err := c.ensureConnected(); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	rows, err := c.conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		cancel()
+		return nil, redactError(err)
+	}
+	cols, err := rows.Columns()
+	if err != nil {
+		rows.Close()
+		cancel()
+		return nil, err
+	}
+	return &RowIterator{rows: rows, cols: cols, cancel: cancel}, nil
+}
+
+// Next 读取下一行，没有更多行或出错时返回 false，此时应检查 Err
+func (it *RowIterator) Next() bool {
+	if it.rows.Next() {
+		return true
+	}
+	it.done = true
+	return false
+}
+
+// Scan 将当前行的列值复制到 dest，规则与 sql.Rows.Scan 相同
+func (it *RowIterator) Scan(dest ...interface{}) error {
+	return it.rows.Scan(dest...)
+}
+
+// Columns 返回结果的列名
+func (it *RowIterator) Columns() []string {
+	return it.cols
+}
+
+// ColumnTypes 返回结果各列的类型信息
+func (it *RowIterator) ColumnTypes() ([]*sql.ColumnType, error) {
+	return it.rows.ColumnTypes()
+}
+
+// Err 返回迭代过程中遇到的错误
+func (it *RowIterator) Err() error {
+	return it.rows.Err()
+}
+
+// Close 结束迭代并释放连接，可重复调用
+// 尚未读完时先取消服务器上的查询，避免 lib/pq 在关闭时读取剩余的全部行；事务中取消查询会使事务失败
+func (it *RowIterator) Close() error {
+	defer it.cancel()
+	if it.done {
+		return it.rows.Close()
+	}
+	// 主动取消引起的错误不报告给调用方
+	it.cancel()
+	it.rows.Close()
+	return nil
+}
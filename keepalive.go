@@ -0,0 +1,57 @@
+package postgres
+
+import (
+	"context"
+	"net"
+	"syscall"
+	"time"
+)
+
+// keepaliveDialer 为连接设置 TCP keepalive 参数
+// lib/pq 不识别 libpq 的 keepalives_* 连接参数（会作为运行时参数发给服务器而报错），因此在拨号时设置
+type keepaliveDialer struct {
+	d net.Dialer
+}
+
+// newKeepaliveDialer 根据配置创建拨号器
+func newKeepaliveDialer(config *Config) *keepaliveDialer {
+	idle := config.KeepalivesIdle
+	if idle == 0 {
+		idle = config.KeepalivesInterval
+	}
+
+	d := net.Dialer{KeepAlive: idle}
+	if config.KeepalivesInterval > 0 || config.KeepalivesCount > 0 {
+		interval, count := config.KeepalivesInterval, config.KeepalivesCount
+		d.Control = func(network, address string, rc syscall.RawConn) error {
+			var sockErr error
+			err := rc.Control(func(fd uintptr) {
+				sockErr = setKeepaliveProbes(fd, interval, count)
+			})
+			if err != nil {
+				return err
+			}
+			return sockErr
+		}
+	}
+	return &keepaliveDialer{d: d}
+}
+
+// keepaliveEnabled 配置中是否启用了 TCP keepalive
+func keepaliveEnabled(config *Config) bool {
+	return config.TCPKeepAlive || config.KeepalivesIdle > 0 || config.KeepalivesInterval > 0 || config.KeepalivesCount > 0
+}
+
+func (k *keepaliveDialer) Dial(network, address string) (net.Conn, error) {
+	return k.d.Dial(network, address)
+}
+
+func (k *keepaliveDialer) DialTimeout(network, address string, timeout time.Duration) (net.Conn, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return k.DialContext(ctx, network, address)
+}
+
+func (k *keepaliveDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	return k.d.DialContext(ctx, network, address)
+}
@@ -0,0 +1,25 @@
+package postgres
+
+import (
+	"syscall"
+	"time"
+)
+
+// setKeepaliveProbes 设置 keepalive 探测间隔与次数
+func setKeepaliveProbes(fd uintptr, interval time.Duration, count int) error {
+	if interval > 0 {
+		secs := int(interval / time.Second)
+		if secs < 1 {
+			secs = 1
+		}
+		if err := syscall.SetsockoptInt(int(fd), syscall.IPPROTO_TCP, syscall.TCP_KEEPINTVL, secs); err != nil {
+			return err
+		}
+	}
+	if count > 0 {
+		if err := syscall.SetsockoptInt(int(fd), syscall.IPPROTO_TCP, syscall.TCP_KEEPCNT, count); err != nil {
+			return err
+		}
+	}
+	return nil
+}
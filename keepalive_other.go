@@ -0,0 +1,12 @@
+//go:build !linux
+
+package postgres
+
+import (
+	"time"
+)
+
+// setKeepaliveProbes 非 Linux 平台仅支持空闲时间（由 net.Dialer.KeepAlive 设置），忽略间隔与次数
+func setKeepaliveProbes(fd uintptr, interval time.Duration, count int) error {
+	return nil
+}
@@ -0,0 +1,165 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// progressInterval 维护命令执行期间输出进度的间隔
+const progressInterval = 5 * time.Second
+
+// maintenance 长时间运行的维护命令
+type maintenance struct {
+	name          string // 命令名，用于提示信息
+	progressQuery string // 查询进度（阶段、已完成块数、总块数）的 SQL，$1 为执行命令的后端 PID
+	noTxn         bool   // 不能在事务块中执行
+}
+
+// 各维护命令对应的进度视图（PostgreSQL 12 起提供，ANALYZE 为 13 起）
+const (
+	progressVacuum      = "SELECT phase, heap_blks_scanned, heap_blks_total FROM pg_catalog.pg_stat_progress_vacuum WHERE pid = $1"
+	progressAnalyze     = "SELECT phase, sample_blks_scanned, sample_blks_total FROM pg_catalog.pg_stat_progress_analyze WHERE pid = $1"
+	progressCluster     = "SELECT phase, heap_blks_scanned, heap_blks_total FROM pg_catalog.pg_stat_progress_cluster WHERE pid = $1"
+	progressCreateIndex = "SELECT phase, blocks_done, blocks_total FROM pg_catalog.pg_stat_progress_create_index WHERE pid = $1"
+)
+
+// maintenanceCommand 识别 VACUUM、ANALYZE、CLUSTER、REINDEX、CREATE/DROP INDEX、REFRESH MATERIALIZED VIEW 等维护命令
+// 这些命令不受语句超时限制，执行期间定期输出进度
+func maintenanceCommand(sqlStr string) (maintenance, bool) {
+	words := topLevelWords(stripLeadingComments(sqlStr))
+	if len(words) == 0 {
+		return maintenance{}, false
+	}
+	concurrently := containsWord(words, "CONCURRENTLY")
+
+	switch words[0] {
+	case "VACUUM":
+		// VACUUM FULL 的进度在 pg_stat_progress_cluster 中
+		if containsWord(words, "FULL") {
+			return maintenance{name: "VACUUM", progressQuery: progressCluster, noTxn: true}, true
+		}
+		return maintenance{name: "VACUUM", progressQuery: progressVacuum, noTxn: true}, true
+	case "ANALYZE", "ANALYSE":
+		return maintenance{name: "ANALYZE", progressQuery: progressAnalyze}, true
+	case "CLUSTER":
+		return maintenance{name: "CLUSTER", progressQuery: progressCluster}, true
+	case "REINDEX":
+		noTxn := concurrently || len(words) > 1 && (words[1] == "DATABASE" || words[1] == "SYSTEM")
+		return maintenance{name: "REINDEX", progressQuery: progressCreateIndex, noTxn: noTxn}, true
+	case "CREATE":
+		// CREATE [UNIQUE] INDEX
+		if containsWord(words[1:min(len(words), 3)], "INDEX") {
+			return maintenance{name: "CREATE INDEX", progressQuery: progressCreateIndex, noTxn: concurrently}, true
+		}
+	case "DROP":
+		if len(words) > 1 && words[1] == "INDEX" && concurrently {
+			return maintenance{name: "DROP INDEX CONCURRENTLY", noTxn: true}, true
+		}
+	case "REFRESH":
+		// 没有进度视图；CONCURRENTLY 可以在事务块中执行
+		return maintenance{name: "REFRESH MATERIALIZED VIEW"}, true
+	}
+	return maintenance{}, false
+}
+
+// checkMaintenance 在事务块中拒绝不能在事务中执行的维护命令，避免服务器报错使整个事务失效
+func (c *CLI) checkMaintenance(m maintenance) error {
+	if m.noTxn && c.inTransaction {
+		return fmt.Errorf("%s cannot run inside a transaction block; COMMIT or ROLLBACK first", m.name)
+	}
+	return nil
+}
+
+// watchProgress 在另一个连接上定期查询维护命令的进度并输出，返回停止函数
+func (c *CLI) watchProgress(m maintenance) func() {
+	if m.progressQuery == "" {
+		return func() {}
+	}
+	var pid int
+	if err := c.conn.QueryRowContext(context.Background(), "SELECT pg_backend_pid()").Scan(&pid); err != nil {
+		return func() {}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(progressInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				c.printProgress(ctx, m, pid)
+			}
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-stopped
+	}
+}
+
+// printProgress 输出一次进度，如 "VACUUM: scanning heap, 1200 of 5000 blocks (24.0%)"
+// 查询失败（服务器版本过低、连接池已满等）时不输出
+func (c *CLI) printProgress(ctx context.Context, m maintenance, pid int) {
+	ctx, cancel := context.WithTimeout(ctx, progressInterval)
+	defer cancel()
+
+	var phase string
+	var done, total int64
+	if err := c.db.QueryRowContext(ctx, m.progressQuery, pid).Scan(&phase, &done, &total); err != nil {
+		return
+	}
+
+	msg := fmt.Sprintf("%s: %s", m.name, phase)
+	if total > 0 {
+		msg += fmt.Sprintf(", %d of %d blocks (%.1f%%)", done, total, float64(done)*100/float64(total))
+	}
+	fmt.Fprintf(c.term, "%s\n", c.style(ansiDim, msg))
+}
+
+// concurrentRefreshHint 服务器未给出提示时，CONCURRENTLY 刷新失败的说明
+const concurrentRefreshHint = "REFRESH MATERIALIZED VIEW CONCURRENTLY requires a populated materialized view with a unique index on plain columns and no WHERE clause; create one, or refresh without concurrently"
+
+// handleRefresh 处理 \refresh NAME [concurrently]：刷新物化视图，不受语句超时限制，完成后报告耗时
+// 视图名与 \preview 一样经 qualifiedName 加双引号，不会被当作 SQL 拼接；CONCURRENTLY 因缺少唯一索引等原因失败时给出提示
+func (c *CLI) handleRefresh(args []string) {
+	var name string
+	concurrently := false
+	for _, arg := range args {
+		switch {
+		case strings.EqualFold(arg, "concurrently"):
+			concurrently = true
+		case name == "":
+			name = arg
+		default:
+			fmt.Fprintf(c.term, "\\refresh: too many arguments\n")
+			return
+		}
+	}
+	if name == "" {
+		fmt.Fprintf(c.term, "\\refresh: missing materialized view name\n")
+		return
+	}
+
+	sqlStr := "REFRESH MATERIALIZED VIEW " + qualifiedName(name)
+	if concurrently {
+		sqlStr = "REFRESH MATERIALIZED VIEW CONCURRENTLY " + qualifiedName(name)
+	}
+	start := time.Now()
+	err := c.executeSQL(sqlStr)
+	if err == nil {
+		fmt.Fprintf(c.term, "Materialized view %s refreshed in %s.\n", name, formatDuration(time.Since(start)))
+		return
+	}
+
+	// 缺少唯一索引（55000）与未填充（0A000）时服务器错误本身不说明如何解决
+	if concurrently {
+		c.printHint(err, concurrentRefreshHint, "55000", "0A000")
+	}
+}
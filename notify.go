@@ -0,0 +1,96 @@
+package postgres
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// syncListener 将执行成功的 LISTEN/UNLISTEN 同步到接收通知的独立连接
+// lib/pq 的普通连接会丢弃异步通知，因此由 pq.Listener 在另一个连接上监听同样的频道
+func (c *CLI) syncListener(sqlStr string) {
+	words := topLevelWords(stripLeadingComments(sqlStr))
+	if len(words) == 0 {
+		return
+	}
+
+	var err error
+	switch words[0] {
+	case "LISTEN":
+		err = c.listen(nameAfterKeyword(sqlStr, "LISTEN"))
+	case "UNLISTEN":
+		if len(words) == 1 {
+			// UNLISTEN *
+			err = c.unlisten("")
+		} else {
+			err = c.unlisten(nameAfterKeyword(sqlStr, "UNLISTEN"))
+		}
+	}
+	if err != nil {
+		c.printError(err)
+	}
+}
+
+// listen 在通知连接上监听频道，首次使用时建立连接
+func (c *CLI) listen(channel string) error {
+	if channel == "" {
+		return nil
+	}
+	if c.listener == nil {
+		dsn := c.buildDSN(hostPort{host: c.host, port: c.port}, c.database)
+		c.listener = pq.NewListener(dsn, 10*time.Second, time.Minute, nil)
+	}
+	if err := c.listener.Listen(channel); err != nil && err != pq.ErrChannelAlreadyOpen {
+		return redactError(err)
+	}
+	return nil
+}
+
+// unlisten 取消监听频道，channel 为空时取消全部
+func (c *CLI) unlisten(channel string) error {
+	if c.listener == nil {
+		return nil
+	}
+	if channel == "" {
+		return c.listener.UnlistenAll()
+	}
+	if err := c.listener.Unlisten(channel); err != nil && err != pq.ErrChannelNotOpen {
+		return err
+	}
+	return nil
+}
+
+// printNotifications 输出已收到的异步通知（不等待），格式与 psql 一致
+func (c *CLI) printNotifications() {
+	if c.listener == nil {
+		return
+	}
+	for {
+		select {
+		case n := <-c.listener.Notify:
+			// 重新连接后会收到 nil，期间的通知可能已丢失
+			if n == nil {
+				continue
+			}
+			if n.Extra != "" {
+				fmt.Fprintf(c.term, "Asynchronous notification \"%s\" with payload \"%s\" received from server process with PID %d.\n",
+					n.Channel, n.Extra, n.BePid)
+			} else {
+				fmt.Fprintf(c.term, "Asynchronous notification \"%s\" received from server process with PID %d.\n",
+					n.Channel, n.BePid)
+			}
+		default:
+			return
+		}
+	}
+}
+
+// closeListener 关闭通知连接，所有监听随之取消
+func (c *CLI) closeListener() {
+	if c.listener == nil {
+		return
+	}
+	c.listener.Close()
+	c.listener = nil
+}
@@ -0,0 +1,91 @@
+package postgres
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// setOutput 处理 \o：将查询结果写入文件，错误、提示与计时仍输出到终端
+// 输出到文件期间不使用颜色，避免文件中出现 ANSI 转义序列
+//
+//	\o FILE           覆盖写入 FILE
+//	\o >>FILE         追加到 FILE
+//	\o |tee FILE      同时输出到终端与 FILE（覆盖）
+//	\o |tee -a FILE   同时输出到终端与 FILE（追加）
+//	\o                恢复输出到终端
+func (c *CLI) setOutput(arg string) {
+	arg = strings.TrimSpace(arg)
+	if arg == "" {
+		c.closeOutput()
+		return
+	}
+
+	tee, appendMode := false, false
+	path := arg
+	switch {
+	case strings.HasPrefix(arg, ">>"):
+		appendMode = true
+		path = strings.TrimSpace(arg[2:])
+	case strings.HasPrefix(arg, "|"):
+		fields := strings.Fields(arg[1:])
+		if len(fields) == 0 || fields[0] != "tee" {
+			fmt.Fprintf(c.term, "\\o: only \"|tee [-a] FILE\" is supported as a pipe\n")
+			return
+		}
+		tee = true
+		fields = fields[1:]
+		if len(fields) > 0 && fields[0] == "-a" {
+			appendMode = true
+			fields = fields[1:]
+		}
+		path = strings.Join(fields, " ")
+	}
+	if path == "" {
+		fmt.Fprintf(c.term, "\\o: missing file name\n")
+		return
+	}
+
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if appendMode {
+		flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	}
+	f, err := os.OpenFile(path, flags, 0644)
+	if err != nil {
+		fmt.Fprintf(c.term, "%s: %v\n", path, err)
+		return
+	}
+
+	c.closeOutput()
+	c.outFile = f
+	c.out = f
+	c.color = false
+	if tee {
+		c.out = &teeWriter{c: c, file: f}
+	}
+}
+
+// teeWriter \o |tee 的输出：同时写入终端与文件
+// 每次写入时读取 c.term，\script 开始或停止记录而替换终端后，结果仍输出到当前终端并进入会话记录
+type teeWriter struct {
+	c    *CLI
+	file io.Writer
+}
+
+func (w *teeWriter) Write(p []byte) (int, error) {
+	if _, err := w.c.term.Write(p); err != nil {
+		return 0, err
+	}
+	return w.file.Write(p)
+}
+
+// closeOutput 关闭 \o 打开的文件并恢复输出到终端
+func (c *CLI) closeOutput() {
+	if c.outFile != nil {
+		c.outFile.Close()
+		c.outFile = nil
+		c.color = useColor(c.config.Color, c.reader.Interactive())
+	}
+	c.out = c.term
+}
@@ -0,0 +1,43 @@
+package postgres
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTeeOutputFollowsTranscript(t *testing.T) {
+	c, term := newTestCLI(t)
+	dir := t.TempDir()
+	teeFile := filepath.Join(dir, "tee.txt")
+	transcriptFile := filepath.Join(dir, "transcript.txt")
+
+	c.setOutput("|tee " + teeFile)
+	defer c.closeOutput()
+	if err := c.startTranscript(transcriptFile, false); err != nil {
+		t.Fatal(err)
+	}
+	fmt.Fprintf(c.out, "recorded\n")
+	c.stopTranscript()
+	fmt.Fprintf(c.out, "after\n")
+
+	transcript, err := os.ReadFile(transcriptFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(transcript); !strings.Contains(got, "recorded") || strings.Contains(got, "after") {
+		t.Errorf("transcript = %q, want only the output written while recording", got)
+	}
+	if got := term.String(); !strings.Contains(got, "recorded") || !strings.Contains(got, "after") {
+		t.Errorf("terminal = %q, want both lines", got)
+	}
+	tee, err := os.ReadFile(teeFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := string(tee); got != "recorded\nafter\n" {
+		t.Errorf("tee file = %q, want both lines", got)
+	}
+}
@@ -0,0 +1,79 @@
+package postgres
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// defaultPagerHeight 无法获取终端高度（如 SSH session）且未设置 LINES 时每屏的行数
+const defaultPagerHeight = 24
+
+// morePrompt 分页器在每屏末尾显示的提示
+const morePrompt = "-- More -- (Enter for next page, q to stop) "
+
+// pager 内置分页器（\pset pager on）：查询结果输出满一屏后显示 -- More -- 并等待输入
+// 回车显示下一屏，q 或 Ctrl-C 停止显示，之后的输出被丢弃
+type pager struct {
+	cli     *CLI
+	w       io.Writer
+	height  int  // 每屏显示的行数，不含提示行
+	lines   int  // 当前屏已输出的行数
+	stopped bool // 已停止显示
+}
+
+// Write 逐行写入，满一屏时暂停等待输入；停止显示后丢弃输出但仍报告写入成功
+func (p *pager) Write(b []byte) (int, error) {
+	n := len(b)
+	for len(b) > 0 && !p.stopped {
+		if p.lines >= p.height {
+			p.more()
+			continue
+		}
+		i := bytes.IndexByte(b, '\n')
+		if i < 0 {
+			_, err := p.w.Write(b)
+			return n, err
+		}
+		if _, err := p.w.Write(b[:i+1]); err != nil {
+			return n, err
+		}
+		b = b[i+1:]
+		p.lines++
+	}
+	return n, nil
+}
+
+// more 显示提示并等待输入，之后清除提示行
+func (p *pager) more() {
+	p.cli.reader.SetPrompt(morePrompt)
+	answer, err := p.cli.reader.ReadLine()
+	io.WriteString(p.w, "\033[1A\r\033[2K")
+	if err != nil || strings.EqualFold(strings.TrimSpace(answer), "q") {
+		p.stopped = true
+	}
+	p.lines = 0
+}
+
+// startPager 需要分页时将查询结果的输出替换为分页器，返回恢复输出的函数
+// 仅在开启 \pset pager、交互模式、输出到终端（未 \o）、表格格式（非 CSV 与模板）且不在 \watch 中时分页
+func (c *CLI) startPager() func() {
+	if !c.settings.Pager || !c.reader.Interactive() || c.out != c.term || c.watching || c.settings.Format != "aligned" {
+		return func() {}
+	}
+	c.out = &pager{cli: c, w: c.term, height: c.outputHeight() - 1}
+	return func() { c.out = c.term }
+}
+
+// outputHeight 返回分页的屏幕高度：终端高度、LINES 环境变量依次生效，都无法确定时为 defaultPagerHeight
+func (c *CLI) outputHeight() int {
+	if h := c.reader.Height(); h > 1 {
+		return h
+	}
+	if h, err := strconv.Atoi(os.Getenv("LINES")); err == nil && h > 1 {
+		return h
+	}
+	return defaultPagerHeight
+}
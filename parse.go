@@ -0,0 +1,344 @@
+package postgres
+
+import (
+	"strings"
+)
+
+// sqlScanner 逐字节扫描 SQL，跟踪字符串、引号标识符、注释与 dollar 引用的状态
+type sqlScanner struct {
+	sql string
+	pos int
+}
+
+// skipQuoted 若当前位置是字符串、标识符、注释或 dollar 引用的开始，跳过整段并返回 true
+func (s *sqlScanner) skipQuoted() bool {
+	sql, i := s.sql, s.pos
+	ch := sql[i]
+
+	switch {
+	case ch == '\'':
+		// E'...' 字符串支持反斜杠转义
+		escapes := i > 0 && (sql[i-1] == 'E' || sql[i-1] == 'e') && (i < 2 || !isIdentChar(sql[i-2]))
+		s.pos = skipString(sql, i+1, '\'', escapes)
+		return true
+	case ch == '"':
+		s.pos = skipString(sql, i+1, '"', false)
+		return true
+	case ch == '-' && i+1 < len(sql) && sql[i+1] == '-':
+		end := strings.IndexByte(sql[i:], '\n')
+		if end < 0 {
+			s.pos = len(sql)
+		} else {
+			s.pos = i + end + 1
+		}
+		return true
+	case ch == '/' && i+1 < len(sql) && sql[i+1] == '*':
+		s.pos = skipBlockComment(sql, i)
+		return true
+	case ch == '$':
+		if tag, ok := dollarTag(sql, i); ok {
+			end := strings.Index(sql[i+len(tag):], tag)
+			if end < 0 {
+				s.pos = len(sql)
+			} else {
+				s.pos = i + len(tag) + end + len(tag)
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// skipString 跳过以 quote 结尾的字符串（两个连续 quote 表示转义），返回结束后的位置
+func skipString(sql string, i int, quote byte, escapes bool) int {
+	for i < len(sql) {
+		switch {
+		case escapes && sql[i] == '\\':
+			i += 2
+		case sql[i] == quote:
+			if i+1 < len(sql) && sql[i+1] == quote {
+				i += 2
+				continue
+			}
+			return i + 1
+		default:
+			i++
+		}
+	}
+	return len(sql)
+}
+
+// skipBlockComment 跳过（可嵌套的）块注释，返回结束后的位置
+func skipBlockComment(sql string, i int) int {
+	depth := 0
+	for i < len(sql) {
+		switch {
+		case strings.HasPrefix(sql[i:], "/*"):
+			depth++
+			i += 2
+		case strings.HasPrefix(sql[i:], "*/"):
+			depth--
+			i += 2
+			if depth == 0 {
+				return i
+			}
+		default:
+			i++
+		}
+	}
+	return len(sql)
+}
+
+// dollarTag 识别 $tag$ 形式的 dollar 引用开始标记（$1 等参数占位符不是）
+func dollarTag(sql string, i int) (string, bool) {
+	if i > 0 && isIdentChar(sql[i-1]) {
+		return "", false
+	}
+	j := i + 1
+	if j < len(sql) && sql[j] >= '0' && sql[j] <= '9' {
+		return "", false
+	}
+	for j < len(sql) && isIdentChar(sql[j]) {
+		j++
+	}
+	if j < len(sql) && sql[j] == '$' {
+		return sql[i : j+1], true
+	}
+	return "", false
+}
+
+// inputStatus 分析（可能不完整的）输入，用于判断多行输入是否结束
+// open 为未闭合的结构："'"、"\""、"$"（dollar 引用）、"*"（块注释），否则为空串；
+// depth 为未闭合的括号层数；terminated 表示最后一个有效字符是顶层分号
+func inputStatus(sql string) (open string, depth int, terminated bool) {
+	for i := 0; i < len(sql); {
+		ch := sql[i]
+		switch {
+		case ch == '\'' || ch == '"':
+			escapes := ch == '\'' && i > 0 && (sql[i-1] == 'E' || sql[i-1] == 'e') && (i < 2 || !isIdentChar(sql[i-2]))
+			end, closed := scanQuoted(sql, i+1, ch, escapes)
+			if !closed {
+				return string(ch), depth, false
+			}
+			i = end
+			terminated = false
+			continue
+		case ch == '-' && i+1 < len(sql) && sql[i+1] == '-':
+			end := strings.IndexByte(sql[i:], '\n')
+			if end < 0 {
+				return "", depth, terminated
+			}
+			i += end + 1
+			continue
+		case ch == '/' && i+1 < len(sql) && sql[i+1] == '*':
+			end := skipBlockComment(sql, i)
+			if !strings.HasSuffix(sql[:end], "*/") || end-i < 4 {
+				return "*", depth, false
+			}
+			i = end
+			continue
+		case ch == '$':
+			if tag, ok := dollarTag(sql, i); ok {
+				end := strings.Index(sql[i+len(tag):], tag)
+				if end < 0 {
+					return "$", depth, false
+				}
+				i += len(tag) + end + len(tag)
+				terminated = false
+				continue
+			}
+			terminated = false
+		case ch == '(':
+			depth++
+			terminated = false
+		case ch == ')':
+			depth--
+			terminated = false
+		case ch == ';':
+			terminated = depth <= 0
+		case ch == ' ' || ch == '\t' || ch == '\r' || ch == '\n':
+		default:
+			terminated = false
+		}
+		i++
+	}
+	return "", depth, terminated
+}
+
+// scanQuoted 扫描以 quote 结尾的字符串或标识符，返回结束后的位置以及是否已闭合
+func scanQuoted(sql string, i int, quote byte, escapes bool) (int, bool) {
+	for i < len(sql) {
+		switch {
+		case escapes && sql[i] == '\\':
+			i += 2
+		case sql[i] == quote:
+			if i+1 < len(sql) && sql[i+1] == quote {
+				i += 2
+				continue
+			}
+			return i + 1, true
+		default:
+			i++
+		}
+	}
+	return len(sql), false
+}
+
+// splitStatements 按顶层分号拆分为多条语句，忽略字符串、注释与 dollar 引用中的分号
+// 返回的语句已去除首尾空白，不含结尾分号，空语句被丢弃
+func splitStatements(sql string) []string {
+	var stmts []string
+	s := &sqlScanner{sql: sql}
+	start := 0
+
+	for s.pos < len(sql) {
+		if s.skipQuoted() {
+			continue
+		}
+		if sql[s.pos] == ';' {
+			if stmt := strings.TrimSpace(sql[start:s.pos]); stmt != "" {
+				stmts = append(stmts, stmt)
+			}
+			start = s.pos + 1
+		}
+		s.pos++
+	}
+	if stmt := strings.TrimSpace(sql[start:]); stmt != "" {
+		stmts = append(stmts, stmt)
+	}
+	return stmts
+}
+
+// stripLeadingComments 去除语句开头的空白、行注释与块注释，用于判断语句类型
+func stripLeadingComments(sql string) string {
+	for {
+		sql = strings.TrimLeft(sql, " \t\r\n")
+		switch {
+		case strings.HasPrefix(sql, "--"):
+			end := strings.IndexByte(sql, '\n')
+			if end < 0 {
+				return ""
+			}
+			sql = sql[end+1:]
+		case strings.HasPrefix(sql, "/*"):
+			sql = sql[skipBlockComment(sql, 0):]
+		default:
+			return sql
+		}
+	}
+}
+
+// topLevelWords 返回语句中位于最外层括号之外的单词（大写），忽略字符串、注释与 dollar 引用
+func topLevelWords(sql string) []string {
+	var words []string
+	s := &sqlScanner{sql: sql}
+	depth := 0
+
+	for s.pos < len(sql) {
+		if s.skipQuoted() {
+			continue
+		}
+		ch := sql[s.pos]
+		switch {
+		case ch == '(':
+			depth++
+		case ch == ')':
+			depth--
+		case isIdentChar(ch):
+			end := s.pos
+			for end < len(sql) && (isIdentChar(sql[end]) || sql[end] == '$') {
+				end++
+			}
+			if depth == 0 {
+				words = append(words, strings.ToUpper(sql[s.pos:end]))
+			}
+			s.pos = end
+			continue
+		}
+		s.pos++
+	}
+	return words
+}
+
+// cteMainKeyword 在 WITH 之后的顶层单词中找到主语句的关键字，找不到时返回空串
+// CTE 的定义体位于括号内，不会出现在顶层单词中
+func cteMainKeyword(words []string) string {
+	for _, w := range words {
+		switch w {
+		case "SELECT", "INSERT", "UPDATE", "DELETE", "MERGE", "VALUES", "TABLE":
+			return w
+		}
+	}
+	return ""
+}
+
+// containsWord 判断单词列表中是否包含指定单词
+func containsWord(words []string, word string) bool {
+	for _, w := range words {
+		if w == word {
+			return true
+		}
+	}
+	return false
+}
+
+// nameAfterKeyword 返回语句开头关键字之后的名称（如 EXECUTE name、LISTEN channel）
+// 未加引号的名称转为小写；语句不以该关键字开头时返回空串
+func nameAfterKeyword(sqlStr, keyword string) string {
+	sqlStr = stripLeadingComments(sqlStr)
+	n := len(keyword)
+	if len(sqlStr) <= n || !strings.EqualFold(sqlStr[:n], keyword) || isIdentChar(sqlStr[n]) {
+		return ""
+	}
+	rest := strings.TrimLeft(sqlStr[n:], " \t\r\n")
+
+	if strings.HasPrefix(rest, "\"") {
+		var sb strings.Builder
+		for i := 1; i < len(rest); i++ {
+			if rest[i] == '"' {
+				if i+1 < len(rest) && rest[i+1] == '"' {
+					sb.WriteByte('"')
+					i++
+					continue
+				}
+				return sb.String()
+			}
+			sb.WriteByte(rest[i])
+		}
+		return ""
+	}
+
+	end := 0
+	for end < len(rest) && (isIdentChar(rest[end]) || rest[end] == '$') {
+		end++
+	}
+	return strings.ToLower(rest[:end])
+}
+
+// isSelectInto 判断语句是否将查询结果写入新表（SELECT ... INTO、CREATE TABLE ... AS、CREATE MATERIALIZED VIEW ... AS）
+// 这类语句不返回结果集，命令标签为 SELECT n
+func isSelectInto(sqlStr string) bool {
+	words := topLevelWords(stripLeadingComments(sqlStr))
+	if len(words) < 2 {
+		return false
+	}
+
+	switch words[0] {
+	case "SELECT":
+		return containsWord(words, "INTO")
+	case "WITH":
+		// WITH x AS (...) SELECT ... INTO t 同样写入新表
+		return cteMainKeyword(words[1:]) == "SELECT" && containsWord(words, "INTO")
+	case "CREATE":
+		for i, w := range words[1:] {
+			switch w {
+			case "GLOBAL", "LOCAL", "TEMPORARY", "TEMP", "UNLOGGED":
+				continue
+			case "TABLE", "MATERIALIZED":
+				return containsWord(words[i+2:], "AS")
+			}
+			return false
+		}
+	}
+	return false
+}
@@ -0,0 +1,90 @@
+package postgres
+
+import "testing"
+
+func TestStripLeadingComments(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+		want string
+	}{
+		{"no comment", "SELECT 1", "SELECT 1"},
+		{"leading whitespace", " \t\r\nSELECT 1", "SELECT 1"},
+		{"line comment", "-- note\nSELECT 1", "SELECT 1"},
+		{"several line comments", "-- one\n  -- two\nINSERT INTO t VALUES (1)", "INSERT INTO t VALUES (1)"},
+		{"block comment", "/* note */ BEGIN", "BEGIN"},
+		{"multi-line block comment", "/* a\n b\n*/\nWITH x AS (SELECT 1) SELECT * FROM x", "WITH x AS (SELECT 1) SELECT * FROM x"},
+		{"nested block comment", "/* outer /* inner */ still outer */ SELECT 1", "SELECT 1"},
+		{"mixed comments", "-- a\n/* b */ -- c\n/* d */BEGIN", "BEGIN"},
+		{"comment after statement kept", "SELECT 1 -- trailing", "SELECT 1 -- trailing"},
+		{"unterminated line comment", "-- SELECT 1", ""},
+		{"unterminated block comment", "/* SELECT 1", ""},
+		{"unterminated nested block comment", "/* /* */ SELECT 1", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := stripLeadingComments(tt.sql); got != tt.want {
+				t.Errorf("stripLeadingComments(%q) = %q, want %q", tt.sql, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLeadingCommentsStatementType(t *testing.T) {
+	tests := []struct {
+		name    string
+		sql     string
+		query   bool
+		txnType string
+	}{
+		{"select", "SELECT 1", true, ""},
+		{"line comment before select", "-- count\nSELECT count(*) FROM t", true, ""},
+		{"block comment before select", "/* count */ SELECT count(*) FROM t", true, ""},
+		{"nested comment before select", "/* a /* b */ c */ SELECT 1", true, ""},
+		{"line comment before insert", "-- load\nINSERT INTO t VALUES (1)", false, ""},
+		{"block comment before insert returning", "/* load */ INSERT INTO t VALUES (1) RETURNING id", true, ""},
+		{"line comment before begin", "-- start\nBEGIN", false, "BEGIN"},
+		{"block comment before begin", "/* start */ BEGIN ISOLATION LEVEL SERIALIZABLE", false, "BEGIN"},
+		{"nested comment before commit", "/* a /* b */ */ COMMIT", false, "COMMIT"},
+		{"comment before rollback to savepoint", "-- undo\nROLLBACK TO SAVEPOINT s", false, ""},
+		{"line comment before with", "-- cte\nWITH x AS (SELECT 1) SELECT * FROM x", true, ""},
+		{"block comment before data-modifying with", "/* cte */ WITH x AS (SELECT 1) DELETE FROM t", false, ""},
+		{"keyword inside comment ignored", "/* BEGIN */ SELECT 1", true, ""},
+		{"unterminated line comment", "-- BEGIN", false, ""},
+		{"unterminated block comment", "/* SELECT 1", false, ""},
+		{"unterminated nested block comment", "/* /* */ BEGIN", false, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isQuery(tt.sql); got != tt.query {
+				t.Errorf("isQuery(%q) = %v, want %v", tt.sql, got, tt.query)
+			}
+			if got := transactionCommand(tt.sql); got != tt.txnType {
+				t.Errorf("transactionCommand(%q) = %q, want %q", tt.sql, got, tt.txnType)
+			}
+		})
+	}
+}
+
+func TestIsSelectInto(t *testing.T) {
+	tests := []struct {
+		sql  string
+		want bool
+	}{
+		{"SELECT * INTO t FROM u", true},
+		{"select 1", false},
+		{"WITH x AS (SELECT 1 AS a) SELECT a INTO t FROM x", true},
+		{"-- copy\nWITH x AS (SELECT a INTO y FROM u) SELECT a FROM x", false},
+		{"WITH x AS (SELECT 1) INSERT INTO t SELECT * FROM x", false},
+		{"WITH x AS (SELECT 1) SELECT * FROM x", false},
+		{"CREATE TABLE t AS SELECT 1", true},
+		{"CREATE UNLOGGED TABLE t AS SELECT 1", true},
+		{"CREATE MATERIALIZED VIEW v AS SELECT 1", true},
+		{"CREATE TABLE t (a int)", false},
+	}
+	for _, tt := range tests {
+		if got := isSelectInto(tt.sql); got != tt.want {
+			t.Errorf("isSelectInto(%q) = %v, want %v", tt.sql, got, tt.want)
+		}
+	}
+}
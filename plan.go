@@ -0,0 +1,186 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// planNode EXPLAIN (FORMAT JSON) 输出中的计划节点
+type planNode struct {
+	NodeType           string     `json:"Node Type"`
+	ParentRelationship string     `json:"Parent Relationship"`
+	SubplanName        string     `json:"Subplan Name"`
+	JoinType           string     `json:"Join Type"`
+	Strategy           string     `json:"Strategy"`
+	RelationName       string     `json:"Relation Name"`
+	Alias              string     `json:"Alias"`
+	IndexName          string     `json:"Index Name"`
+	CTEName            string     `json:"CTE Name"`
+	FunctionName       string     `json:"Function Name"`
+	StartupCost        float64    `json:"Startup Cost"`
+	TotalCost          float64    `json:"Total Cost"`
+	PlanRows           float64    `json:"Plan Rows"`
+	ActualStartupTime  *float64   `json:"Actual Startup Time"`
+	ActualTotalTime    *float64   `json:"Actual Total Time"`
+	ActualRows         float64    `json:"Actual Rows"`
+	ActualLoops        float64    `json:"Actual Loops"`
+	IndexCond          string     `json:"Index Cond"`
+	RecheckCond        string     `json:"Recheck Cond"`
+	HashCond           string     `json:"Hash Cond"`
+	MergeCond          string     `json:"Merge Cond"`
+	JoinFilter         string     `json:"Join Filter"`
+	Filter             string     `json:"Filter"`
+	SortKey            []string   `json:"Sort Key"`
+	GroupKey           []string   `json:"Group Key"`
+	Plans              []planNode `json:"Plans"`
+}
+
+// label 按 EXPLAIN 文本格式的习惯生成节点标题，如 "Hash Left Join"、"Index Scan using users_pkey on users u"
+func (n *planNode) label() string {
+	name := n.NodeType
+	if n.JoinType != "" && n.JoinType != "Inner" {
+		if name == "Nested Loop" {
+			name += " " + n.JoinType + " Join"
+		} else {
+			name = strings.Replace(name, " Join", " "+n.JoinType+" Join", 1)
+		}
+	}
+	if n.NodeType == "Aggregate" && n.Strategy != "" && n.Strategy != "Plain" {
+		// Sorted -> GroupAggregate，Hashed -> HashAggregate，Mixed -> MixedAggregate
+		name = map[string]string{"Sorted": "GroupAggregate", "Hashed": "HashAggregate", "Mixed": "MixedAggregate"}[n.Strategy]
+	}
+	if n.IndexName != "" {
+		name += " using " + n.IndexName
+	}
+	switch {
+	case n.RelationName != "":
+		name += " on " + n.RelationName
+		if n.Alias != "" && n.Alias != n.RelationName {
+			name += " " + n.Alias
+		}
+	case n.CTEName != "":
+		name += " on " + n.CTEName
+	case n.FunctionName != "":
+		name += " on " + n.FunctionName
+	}
+	if n.SubplanName != "" {
+		name = n.SubplanName + ": " + name
+	}
+	return name
+}
+
+// details 返回节点的条件与排序、分组键
+func (n *planNode) details() []string {
+	var lines []string
+	for _, d := range []struct{ name, value string }{
+		{"Index Cond", n.IndexCond},
+		{"Recheck Cond", n.RecheckCond},
+		{"Hash Cond", n.HashCond},
+		{"Merge Cond", n.MergeCond},
+		{"Join Filter", n.JoinFilter},
+		{"Filter", n.Filter},
+		{"Sort Key", strings.Join(n.SortKey, ", ")},
+		{"Group Key", strings.Join(n.GroupKey, ", ")},
+	} {
+		if d.value != "" {
+			lines = append(lines, d.name+": "+d.value)
+		}
+	}
+	return lines
+}
+
+// showPlan 处理 \plan [analyze] [QUERY]：以 EXPLAIN (FORMAT JSON) 获取计划并输出为带估算代价与行数的树
+// analyze 时执行语句并显示实际耗时、行数与循环次数；省略 QUERY 时使用最近执行的 SQL
+func (c *CLI) showPlan(args string) {
+	query := strings.TrimSpace(args)
+	analyze := false
+	if fields := strings.Fields(query); len(fields) > 0 && strings.EqualFold(fields[0], "analyze") {
+		analyze = true
+		query = strings.TrimSpace(query[len(fields[0]):])
+	}
+	if query == "" {
+		query = strings.TrimSpace(c.lastQuery)
+	}
+	query = strings.TrimSuffix(strings.TrimSpace(c.interpolate(query)), ";")
+	if query == "" {
+		fmt.Fprintf(c.term, "\\plan cannot be used with an empty query\n")
+		return
+	}
+
+	explain := "EXPLAIN (FORMAT JSON) " + query
+	if analyze {
+		// EXPLAIN ANALYZE 会真正执行语句，与直接执行时一样检查只读模式并请求确认
+		explain = "EXPLAIN (ANALYZE, FORMAT JSON) " + query
+		if err := c.checkReadOnly(explain); err != nil {
+			c.printError(err)
+			return
+		}
+		if !c.confirmDestructive(query) {
+			fmt.Fprintf(c.term, "Statement cancelled.\n")
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.queryTimeout())
+	defer cancel()
+	ctx, stopInterrupt := interruptContext(ctx)
+	defer stopInterrupt()
+
+	var out []byte
+	err := c.conn.QueryRowContext(ctx, explain).Scan(&out)
+	c.trackTransaction(err)
+	if err != nil {
+		c.printError(err)
+		return
+	}
+	var plans []struct {
+		Plan          planNode `json:"Plan"`
+		PlanningTime  *float64 `json:"Planning Time"`
+		ExecutionTime *float64 `json:"Execution Time"`
+	}
+	if err := json.Unmarshal(out, &plans); err != nil || len(plans) == 0 {
+		c.printError(fmt.Errorf("could not parse the plan: %v", err))
+		return
+	}
+
+	p := plans[0]
+	c.printPlanNode(&p.Plan, "", "", analyze)
+	if p.PlanningTime != nil {
+		fmt.Fprintf(c.out, "Planning Time: %.3f ms\n", *p.PlanningTime)
+	}
+	if p.ExecutionTime != nil {
+		fmt.Fprintf(c.out, "Execution Time: %.3f ms\n", *p.ExecutionTime)
+	}
+	fmt.Fprintf(c.out, "\n")
+}
+
+// printPlanNode 输出一个节点及其子节点；first 为节点所在行的前缀，rest 为其后各行（条件与子节点）的前缀
+func (c *CLI) printPlanNode(n *planNode, first, rest string, analyze bool) {
+	line := fmt.Sprintf("%s  (cost=%.2f..%.2f rows=%.0f)", c.style(ansiBold, n.label()), n.StartupCost, n.TotalCost, n.PlanRows)
+	switch {
+	case !analyze:
+	case n.ActualLoops == 0:
+		line += " (never executed)"
+	case n.ActualTotalTime != nil:
+		line += fmt.Sprintf(" (actual time=%.3f..%.3f rows=%.0f loops=%.0f)", *n.ActualStartupTime, *n.ActualTotalTime, n.ActualRows, n.ActualLoops)
+	}
+	fmt.Fprintf(c.out, "%s%s\n", first, line)
+
+	// 条件行与子节点的连线对齐
+	detailPrefix := rest + "│  "
+	if len(n.Plans) == 0 {
+		detailPrefix = rest + "   "
+	}
+	for _, d := range n.details() {
+		fmt.Fprintf(c.out, "%s%s\n", detailPrefix, c.style(ansiDim, d))
+	}
+	for i := range n.Plans {
+		if i == len(n.Plans)-1 {
+			c.printPlanNode(&n.Plans[i], rest+"└─ ", rest+"   ", analyze)
+		} else {
+			c.printPlanNode(&n.Plans[i], rest+"├─ ", rest+"│  ", analyze)
+		}
+	}
+}
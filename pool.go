@@ -0,0 +1,38 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// PoolStats 返回连接池的统计信息（打开、使用中、空闲的连接数以及等待情况），未连接时返回零值
+// 会话固定占用一个连接，因此使用中的连接数至少为 1
+func (c *CLI) PoolStats() sql.DBStats {
+	if c.db == nil {
+		return sql.DBStats{}
+	}
+	return c.db.Stats()
+}
+
+// showPoolStats 输出连接池统计（\poolstats），用于调整 MaxOpenConns、MaxIdleConns 等配置
+func (c *CLI) showPoolStats() {
+	if c.db == nil {
+		fmt.Fprintf(c.term, "You are currently not connected to a database.\n")
+		return
+	}
+
+	s := c.db.Stats()
+	maxOpen := "unlimited"
+	if s.MaxOpenConnections > 0 {
+		maxOpen = fmt.Sprintf("%d", s.MaxOpenConnections)
+	}
+	fmt.Fprintf(c.term, "Max open connections:     %s\n", maxOpen)
+	fmt.Fprintf(c.term, "Open connections:         %d\n", s.OpenConnections)
+	fmt.Fprintf(c.term, "In use:                   %d\n", s.InUse)
+	fmt.Fprintf(c.term, "Idle:                     %d\n", s.Idle)
+	fmt.Fprintf(c.term, "Wait count:               %d\n", s.WaitCount)
+	fmt.Fprintf(c.term, "Wait duration:            %v\n", s.WaitDuration)
+	fmt.Fprintf(c.term, "Closed (max idle):        %d\n", s.MaxIdleClosed)
+	fmt.Fprintf(c.term, "Closed (max idle time):   %d\n", s.MaxIdleTimeClosed)
+	fmt.Fprintf(c.term, "Closed (max lifetime):    %d\n", s.MaxLifetimeClosed)
+}
@@ -0,0 +1,57 @@
+package postgres
+
+import (
+	"context"
+	"strings"
+)
+
+// preparedBody 返回 PREPARE name [(types)] AS statement 中 AS 之后的语句
+func preparedBody(prepare string) string {
+	s := &sqlScanner{sql: prepare}
+	depth := 0
+
+	for s.pos < len(prepare) {
+		if s.skipQuoted() {
+			continue
+		}
+		ch := prepare[s.pos]
+		switch {
+		case ch == '(':
+			depth++
+		case ch == ')':
+			depth--
+		case isIdentChar(ch):
+			end := s.pos
+			for end < len(prepare) && isIdentChar(prepare[end]) {
+				end++
+			}
+			if depth == 0 && strings.EqualFold(prepare[s.pos:end], "AS") {
+				return strings.TrimSpace(prepare[end:])
+			}
+			s.pos = end
+			continue
+		}
+		s.pos++
+	}
+	return ""
+}
+
+// resolveExecute 将 EXECUTE name(...) 解析为预备语句的定义，用于判断结果的显示方式与命令类型
+// 不是 EXECUTE 或找不到预备语句时返回原语句，由服务器报告错误
+func (c *CLI) resolveExecute(ctx context.Context, sqlStr string) string {
+	name := nameAfterKeyword(sqlStr, "EXECUTE")
+	if name == "" {
+		return sqlStr
+	}
+
+	var prepare string
+	err := c.conn.QueryRowContext(ctx,
+		"SELECT statement FROM pg_catalog.pg_prepared_statements WHERE name = $1", name).Scan(&prepare)
+	if err != nil {
+		return sqlStr
+	}
+	if body := preparedBody(prepare); body != "" {
+		return body
+	}
+	return sqlStr
+}
@@ -0,0 +1,146 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// defaultPreviewRows \preview 默认显示的行数
+const defaultPreviewRows = 10
+
+// qualifiedName 将 [schema.]name 形式的表名转为加双引号的限定名，未加双引号的部分与服务器一样转为小写
+func qualifiedName(name string) string {
+	schema, rel := splitPattern(name)
+	quoted := pq.QuoteIdentifier(identifierValue(rel))
+	if schema != "" {
+		quoted = pq.QuoteIdentifier(identifierValue(schema)) + "." + quoted
+	}
+	return quoted
+}
+
+// nextTableArg 返回参数中的第一个词及其后的原文；双引号内的空白不分隔，引号保留，供 qualifiedName 区分大小写
+func nextTableArg(s string) (arg, rest string) {
+	s = strings.TrimLeft(s, " \t")
+	inQuote := false
+	for i := 0; i < len(s); i++ {
+		switch ch := s[i]; {
+		case ch == '"':
+			inQuote = !inQuote
+		case !inQuote && (ch == ' ' || ch == '\t'):
+			return s[:i], s[i:]
+		}
+	}
+	return s, ""
+}
+
+// tableArgs 按空白拆分 \preview 等命令的参数，双引号括起的表名可以包含空白
+func tableArgs(s string) []string {
+	var args []string
+	for {
+		arg, rest := nextTableArg(s)
+		if arg == "" {
+			return args
+		}
+		args = append(args, arg)
+		s = rest
+	}
+}
+
+// handlePreview 处理 \preview TABLE [N]：执行 SELECT * FROM TABLE LIMIT N（默认 10 行）
+// 结果按当前的输出格式与扩展显示模式输出
+func (c *CLI) handlePreview(args []string) {
+	if len(args) == 0 {
+		fmt.Fprintf(c.term, "\\preview: missing table name\n")
+		return
+	}
+	if len(args) > 2 {
+		fmt.Fprintf(c.term, "\\preview: too many arguments\n")
+		return
+	}
+	n := defaultPreviewRows
+	if len(args) == 2 {
+		v, err := strconv.Atoi(args[1])
+		if err != nil || v < 1 {
+			fmt.Fprintf(c.term, "\\preview: invalid row count \"%s\"\n", args[1])
+			return
+		}
+		n = v
+	}
+	c.executeSQL(fmt.Sprintf("SELECT * FROM %s LIMIT %d", qualifiedName(args[0]), n))
+}
+
+// handleRandom 处理 \random TABLE [N | P%]：显示表中的随机样本
+// N 行时使用 ORDER BY random() LIMIT N，需要读取整个表，大表上代价较高；
+// P% 时使用 TABLESAMPLE SYSTEM (P) 按数据页抽样，只读取约 P% 的页，但同一页中的行会一起出现
+func (c *CLI) handleRandom(args []string) {
+	if len(args) == 0 {
+		fmt.Fprintf(c.term, "\\random: missing table name\n")
+		return
+	}
+	if len(args) > 2 {
+		fmt.Fprintf(c.term, "\\random: too many arguments\n")
+		return
+	}
+	table := qualifiedName(args[0])
+	sample := strconv.Itoa(defaultPreviewRows)
+	if len(args) == 2 {
+		sample = args[1]
+	}
+
+	if percent, ok := strings.CutSuffix(sample, "%"); ok {
+		p, err := strconv.ParseFloat(percent, 64)
+		// NaN 与任何数比较都为假，需要单独排除
+		if err != nil || math.IsNaN(p) || p <= 0 || p > 100 {
+			fmt.Fprintf(c.term, "\\random: invalid sample percentage \"%s\"\n", sample)
+			return
+		}
+		// 使用解析后的值，0x1p-2 等 Go 接受但服务器不接受的写法也能正确执行
+		c.executeSQL(fmt.Sprintf("SELECT * FROM %s TABLESAMPLE SYSTEM (%s)", table, strconv.FormatFloat(p, 'f', -1, 64)))
+		return
+	}
+
+	n, err := strconv.Atoi(sample)
+	if err != nil || n < 1 {
+		fmt.Fprintf(c.term, "\\random: invalid row count \"%s\"\n", sample)
+		return
+	}
+	fmt.Fprintf(c.term, "%s\n", c.style(ansiDim, "Note: ORDER BY random() reads the whole table; use \\random TABLE P% to sample a percentage of its pages instead."))
+	c.executeSQL(fmt.Sprintf("SELECT * FROM %s ORDER BY random() LIMIT %d", table, n))
+}
+
+// handleCount 处理 \count TABLE [[WHERE] CONDITION]：执行 SELECT count(*) 并只输出行数，便于在脚本中使用
+func (c *CLI) handleCount(args string) {
+	table, rest := nextTableArg(args)
+	if table == "" {
+		fmt.Fprintf(c.term, "\\count: missing table name\n")
+		return
+	}
+	sqlStr := "SELECT count(*) FROM " + qualifiedName(table)
+	// 条件取原文，保留字符串常量中的空白
+	cond := strings.TrimSpace(rest)
+	if word, after := nextTableArg(cond); strings.EqualFold(word, "where") {
+		cond = strings.TrimSpace(after)
+	}
+	if cond != "" {
+		sqlStr += " WHERE " + c.interpolate(cond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.queryTimeout())
+	defer cancel()
+	ctx, stopInterrupt := interruptContext(ctx)
+	defer stopInterrupt()
+
+	var n int64
+	err := c.conn.QueryRowContext(ctx, sqlStr).Scan(&n)
+	c.trackTransaction(err)
+	if err != nil {
+		c.printError(err)
+		return
+	}
+	fmt.Fprintf(c.out, "%d\n", n)
+}
@@ -0,0 +1,44 @@
+package postgres
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTableArgs(t *testing.T) {
+	tests := []struct {
+		args string
+		want []string
+	}{
+		{"", nil},
+		{" users 5", []string{"users", "5"}},
+		{`"My Table" 5`, []string{`"My Table"`, "5"}},
+		{` public."My Table"  10%`, []string{`public."My Table"`, "10%"}},
+		{`"a "" b"`, []string{`"a "" b"`}},
+	}
+	for _, tt := range tests {
+		if got := tableArgs(tt.args); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("tableArgs(%q) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestQualifiedName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"users", `"users"`},
+		{"Users", `"users"`},
+		{"public.Users", `"public"."users"`},
+		{`"My Table"`, `"My Table"`},
+		{`"My Schema"."My Table"`, `"My Schema"."My Table"`},
+		{`"a""b"`, `"a""b"`},
+		{`"a.b".c`, `"a.b"."c"`},
+	}
+	for _, tt := range tests {
+		if got := qualifiedName(tt.name); got != tt.want {
+			t.Errorf("qualifiedName(%q) = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package postgres
+
+import (
+	"strconv"
+	"strings"
+)
+
+// 未设置 PROMPT1/PROMPT2 时的默认提示符：db=> 、db*=> 与续行的 db->（引号内为 db'>，括号内为 db(>）
+const (
+	defaultPrompt1 = "%/%x=> "
+	defaultPrompt2 = "%/%R> "
+)
+
+// expandPrompt 展开提示符中的 % 转义（\set PROMPT1、PROMPT2）
+//
+//	%n 用户名  %/ 数据库名  %~ 数据库名（与用户同名时为 ~）
+//	%m 主机名（第一个点之前）  %M 完整主机名  %> 端口
+//	%x 事务状态（事务中为 *，事务失败时为 !）  %# 超级用户为 #，否则为 >
+//	%R 首行为 =，续行为 -，在未闭合的引号、注释或括号内时为 '、"、$、*、(  %% 百分号
+//
+// state 为 %R 的取值
+func (c *CLI) expandPrompt(format string, state string) string {
+	var sb strings.Builder
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' || i+1 >= len(format) {
+			sb.WriteByte(format[i])
+			continue
+		}
+		i++
+		switch format[i] {
+		case 'n':
+			sb.WriteString(c.config.Username)
+		case '/':
+			sb.WriteString(c.database)
+		case '~':
+			if c.database == c.config.Username {
+				sb.WriteString("~")
+			} else {
+				sb.WriteString(c.database)
+			}
+		case 'm':
+			host := c.host
+			if j := strings.IndexByte(host, '.'); j > 0 {
+				host = host[:j]
+			}
+			sb.WriteString(host)
+		case 'M':
+			sb.WriteString(c.host)
+		case '>':
+			sb.WriteString(strconv.Itoa(c.port))
+		case 'x':
+			switch {
+			case c.txnFailed:
+				sb.WriteString("!")
+			case c.inTransaction:
+				sb.WriteString("*")
+			}
+		case '#':
+			if c.superuser {
+				sb.WriteString("#")
+			} else {
+				sb.WriteString(">")
+			}
+		case 'R':
+			sb.WriteString(state)
+		case '%':
+			sb.WriteByte('%')
+		default:
+			sb.WriteByte('%')
+			sb.WriteByte(format[i])
+		}
+	}
+	return sb.String()
+}
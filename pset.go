@@ -0,0 +1,115 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// handlePset 处理 \pset [option [value]]：不带参数时列出所有选项，否则设置并显示选项
+// 不带值时布尔选项切换状态，title 与 fields 被清除，其余选项只显示当前值
+func (c *CLI) handlePset(args []string) {
+	if len(args) == 0 {
+		fmt.Fprintf(c.term, "%s", c.settings.String())
+		return
+	}
+
+	name := args[0]
+	if name == "x" {
+		name = "expanded"
+	}
+	if len(args) > 1 || setWithoutValue(name) {
+		if err := c.settings.Set(name, strings.Join(args[1:], " ")); err != nil {
+			fmt.Fprintf(c.term, "\\pset: %v\n", err)
+			return
+		}
+	} else if _, err := c.settings.Get(name); err != nil {
+		fmt.Fprintf(c.term, "\\pset: %v\n", err)
+		return
+	}
+	fmt.Fprintf(c.term, "%s\n", c.settings.Describe(name))
+}
+
+// parseToggle 解析 on/off 类选项值，空值表示切换当前状态
+func parseToggle(value string, current bool) (bool, bool) {
+	switch strings.ToLower(value) {
+	case "":
+		return !current, true
+	case "on", "true", "yes", "1":
+		return true, true
+	case "off", "false", "no", "0":
+		return false, true
+	}
+	return false, false
+}
+
+// isNumericType 判断列类型是否为数值类型（按 \pset numericlocale 分组显示）
+func isNumericType(colType *sql.ColumnType) bool {
+	switch colType.DatabaseTypeName() {
+	case "INT2", "INT4", "INT8", "NUMERIC", "FLOAT4", "FLOAT8":
+		return true
+	}
+	return false
+}
+
+// numericSeparators 返回按 \pset numericlocale 显示数值时使用的千位分隔符与小数点，
+// 由区域设置（依次为 LC_ALL、LC_NUMERIC、LANG）的语言与地区决定；C、POSIX 与未列出的区域使用逗号与点
+func numericSeparators() (group, decimal string) {
+	locale := ""
+	for _, name := range []string{"LC_ALL", "LC_NUMERIC", "LANG"} {
+		if locale = os.Getenv(name); locale != "" {
+			break
+		}
+	}
+	// 去掉编码与修饰部分，如 de_DE.UTF-8@euro -> de_DE
+	if i := strings.IndexAny(locale, ".@"); i >= 0 {
+		locale = locale[:i]
+	}
+	lang, region, _ := strings.Cut(locale, "_")
+
+	switch {
+	case region == "CH" && (lang == "de" || lang == "it" || lang == "fr"):
+		return "'", "."
+	case lang == "es" && (region == "MX" || region == "US"):
+		return ",", "."
+	}
+	switch lang {
+	case "de", "es", "it", "nl", "pt", "id", "tr", "da", "el", "ro", "hr", "sl", "sr", "vi":
+		return ".", ","
+	case "fr", "ru", "uk", "be", "bg", "pl", "cs", "sk", "hu", "sv", "nb", "nn", "no", "fi", "et", "lt", "lv":
+		// 以不换行空格分组，避免数值在折行时断开
+		return "\u00a0", ","
+	}
+	return ",", "."
+}
+
+// groupDigits 为数值的整数部分添加千位分隔符 group，并将小数点换为 decimal，如 1234567.89 -> 1,234,567.89
+// 科学计数法、NaN、Infinity 等保持原样
+func groupDigits(s, group, decimal string) string {
+	sign := ""
+	if strings.HasPrefix(s, "-") || strings.HasPrefix(s, "+") {
+		sign, s = s[:1], s[1:]
+	}
+	intPart, frac := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		intPart, frac = s[:i], s[i+1:]
+	}
+	if strings.Trim(intPart, "0123456789") != "" || strings.ContainsAny(frac, "eE") {
+		return sign + s
+	}
+
+	var sb strings.Builder
+	sb.WriteString(sign)
+	for i, ch := range intPart {
+		if i > 0 && (len(intPart)-i)%3 == 0 {
+			sb.WriteString(group)
+		}
+		sb.WriteRune(ch)
+	}
+	if strings.Contains(s, ".") {
+		sb.WriteString(decimal)
+		sb.WriteString(frac)
+	}
+	return sb.String()
+}
@@ -0,0 +1,53 @@
+package postgres
+
+import "testing"
+
+func TestGroupDigits(t *testing.T) {
+	tests := []struct {
+		s, group, decimal string
+		want              string
+	}{
+		{"1234567.89", ",", ".", "1,234,567.89"},
+		{"-1234", ",", ".", "-1,234"},
+		{"123", ",", ".", "123"},
+		{"1234567.89", ".", ",", "1.234.567,89"},
+		{"12.5", ".", ",", "12,5"},
+		{"1234567", " ", ",", "1 234 567"},
+		{"1e+10", ",", ".", "1e+10"},
+		{"NaN", ".", ",", "NaN"},
+		{"-Infinity", ",", ".", "-Infinity"},
+	}
+	for _, tt := range tests {
+		if got := groupDigits(tt.s, tt.group, tt.decimal); got != tt.want {
+			t.Errorf("groupDigits(%q, %q, %q) = %q, want %q", tt.s, tt.group, tt.decimal, got, tt.want)
+		}
+	}
+}
+
+func TestNumericSeparators(t *testing.T) {
+	tests := []struct {
+		locale         string
+		group, decimal string
+	}{
+		{"", ",", "."},
+		{"C", ",", "."},
+		{"en_US.UTF-8", ",", "."},
+		{"de_DE.UTF-8", ".", ","},
+		{"de_CH.UTF-8", "'", "."},
+		{"fr_FR.UTF-8@euro", "\u00a0", ","},
+		{"es_MX", ",", "."},
+		{"pt_BR.UTF-8", ".", ","},
+	}
+	for _, tt := range tests {
+		t.Setenv("LC_ALL", "")
+		t.Setenv("LC_NUMERIC", tt.locale)
+		t.Setenv("LANG", "en_US.UTF-8")
+		if tt.locale == "" {
+			t.Setenv("LANG", "")
+		}
+		group, decimal := numericSeparators()
+		if group != tt.group || decimal != tt.decimal {
+			t.Errorf("numericSeparators() with LC_NUMERIC=%q = %q, %q, want %q, %q", tt.locale, group, decimal, tt.group, tt.decimal)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package postgres
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// queryLogBuffer 日志队列长度，队列满时丢弃记录而不阻塞 REPL
+const queryLogBuffer = 1024
+
+// queryLogger 将执行的语句异步追加到日志文件（Config.LogFile）
+type queryLogger struct {
+	entries chan string
+	wg      sync.WaitGroup
+	dropped int
+}
+
+// newQueryLogger 以追加方式打开日志文件并启动写入协程
+func newQueryLogger(path string) (*queryLogger, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("could not open log file \"%s\": %w", path, err)
+	}
+
+	l := &queryLogger{entries: make(chan string, queryLogBuffer)}
+	l.wg.Add(1)
+	go func() {
+		defer l.wg.Done()
+		defer f.Close()
+		w := bufio.NewWriter(f)
+		for entry := range l.entries {
+			w.WriteString(entry)
+			// 队列暂时为空时刷新，避免日志长时间停留在缓冲区
+			if len(l.entries) == 0 {
+				w.Flush()
+			}
+		}
+		w.Flush()
+	}()
+	return l, nil
+}
+
+// log 记录一条语句：时间、耗时、行数或错误；rows 为 -1 时不记录行数
+func (l *queryLogger) log(stmt string, elapsed time.Duration, rows int64, err error) {
+	if l == nil {
+		return
+	}
+
+	result := "ok"
+	switch {
+	case err != nil:
+		result = "error: " + strings.ReplaceAll(err.Error(), "\n", " ")
+	case rows >= 0:
+		result = fmt.Sprintf("rows=%d", rows)
+	}
+	entry := fmt.Sprintf("%s duration=%.3fms %s\n%s;\n\n",
+		time.Now().Format("2006-01-02 15:04:05.000 -0700"), elapsed.Seconds()*1000, result, stmt)
+
+	select {
+	case l.entries <- entry:
+	default:
+		l.dropped++
+	}
+}
+
+// close 写完队列中的记录并关闭日志文件
+func (l *queryLogger) close() {
+	if l == nil {
+		return
+	}
+	if l.dropped > 0 {
+		l.entries <- fmt.Sprintf("%s %d log entries dropped\n\n", time.Now().Format("2006-01-02 15:04:05.000 -0700"), l.dropped)
+	}
+	close(l.entries)
+	l.wg.Wait()
+}
@@ -1,8 +1,13 @@
 package postgres
 
 import (
+	"bufio"
+	"errors"
 	"io"
-	
+	"strings"
+	"sync"
+	"time"
+
 	"github.com/chzyer/readline"
 )
 
@@ -16,12 +21,25 @@ func (rwc *ReadWriteCloser) Close() error {
 }
 
 // Reader 从终端读取输入（使用 readline 以支持SSH session）
+// 输入不是 TTY 时（如管道）退化为不输出提示符的普通行读取
 type Reader struct {
-	rl *readline.Instance
+	rl         *readline.Instance
+	rwc        *ReadWriteCloser
+	plain      *bufio.Reader
+	prompt     string    // 当前提示符
+	transcript io.Writer // 会话记录（\script），记录交互输入的提示符与输入行
+
+	plainOnce  sync.Once
+	plainLines chan lineResult // 普通模式下由单个读取协程逐行送出的输入
+	plainErr   error           // 读取协程结束时的错误，plainLines 关闭后返回
 }
 
 // NewReader 创建新的 Reader
 func NewReader(term io.ReadWriter) *Reader {
+	if !isTerminal(term) {
+		return &Reader{plain: bufio.NewReader(term)}
+	}
+
 	rwc := &ReadWriteCloser{term}
 	rl, err := readline.NewEx(&readline.Config{
 		Stdin:  rwc,
@@ -33,20 +51,178 @@ func NewReader(term io.ReadWriter) *Reader {
 	if err != nil {
 		panic(err)
 	}
-	return &Reader{rl: rl}
+	return &Reader{rl: rl, rwc: rwc}
+}
+
+// isTerminal 判断输入是否来自 TTY
+// 无法获取文件描述符时（如 SSH session）按交互式终端处理
+func isTerminal(term io.Reader) bool {
+	f, ok := term.(interface{ Fd() uintptr })
+	if !ok {
+		return true
+	}
+	return readline.IsTerminal(int(f.Fd()))
+}
+
+// Interactive 是否为交互式输入
+func (r *Reader) Interactive() bool {
+	return r.rl != nil
+}
+
+// lineResult 读取协程读到的一行输入
+type lineResult struct {
+	line string
+	err  error
+}
+
+// lines 返回普通模式下的输入通道，首次调用时启动唯一的读取协程
+// 所有读取都经过这个协程，超时返回的 ReadLineTimeout 不会留下另一个阻塞的读取把之后的输入取走
+func (r *Reader) lines() <-chan lineResult {
+	r.plainOnce.Do(func() {
+		r.plainLines = make(chan lineResult)
+		go func() {
+			for {
+				line, err := r.plain.ReadString('\n')
+				if err == io.EOF && line != "" {
+					err = nil
+				}
+				if err != nil {
+					r.plainErr = err
+					close(r.plainLines)
+					return
+				}
+				r.plainLines <- lineResult{strings.TrimRight(line, "\r\n"), nil}
+			}
+		}()
+	})
+	return r.plainLines
+}
+
+// readPlain 从普通模式的输入通道读取一行
+func (r *Reader) readPlain(res lineResult, ok bool) (string, error) {
+	if !ok {
+		return "", r.plainErr
+	}
+	return res.line, res.err
 }
 
 // ReadLine 读取一行输入
 func (r *Reader) ReadLine() (string, error) {
-	return r.rl.Readline()
+	if r.plain != nil {
+		res, ok := <-r.lines()
+		return r.readPlain(res, ok)
+	}
+	line, err := r.rl.Readline()
+	if err == nil && r.transcript != nil {
+		io.WriteString(r.transcript, r.prompt+line+"\n")
+	}
+	return line, err
+}
+
+// errIdleTimeout 在超时时间内没有输入
+var errIdleTimeout = errors.New("idle timeout")
+
+// ReadLineTimeout 读取一行输入，timeout 内没有输入时关闭读取器并返回 errIdleTimeout；timeout 为 0 时不限制
+func (r *Reader) ReadLineTimeout(timeout time.Duration) (string, error) {
+	if timeout <= 0 {
+		return r.ReadLine()
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	if r.plain != nil {
+		// 超时后读取协程继续等待，下一次读取取得之后输入的行
+		select {
+		case res, ok := <-r.lines():
+			return r.readPlain(res, ok)
+		case <-timer.C:
+			return "", errIdleTimeout
+		}
+	}
+
+	ch := make(chan lineResult, 1)
+	go func() {
+		line, err := r.ReadLine()
+		ch <- lineResult{line, err}
+	}()
+	select {
+	case res := <-ch:
+		return res.line, res.err
+	case <-timer.C:
+		// 关闭 readline 以结束阻塞中的读取
+		r.Close()
+		return "", errIdleTimeout
+	}
+}
+
+// ReadPassword 以掩码方式读取一行输入，不回显也不记入历史
+// 非交互输入时直接读取一行
+func (r *Reader) ReadPassword(prompt string) (string, error) {
+	if r.plain != nil {
+		return r.ReadLine()
+	}
+	cfg := r.rl.GenPasswordConfig()
+	cfg.Prompt = prompt
+	cfg.Stdin = r.rwc
+	b, err := r.rl.ReadPasswordWithConfig(cfg)
+	// 只记录提示符，不记录密码
+	if err == nil && r.transcript != nil {
+		io.WriteString(r.transcript, prompt+"\n")
+	}
+	return string(b), err
+}
+
+// EnableHighlight 为交互式输入启用 SQL 语法高亮
+func (r *Reader) EnableHighlight() {
+	if r.rl != nil {
+		r.rl.Config.SetPainter(sqlPainter{})
+	}
+}
+
+// Width 返回终端宽度（列数），非交互式输入或无法获取时返回 0
+func (r *Reader) Width() int {
+	if r.rl == nil || r.rl.Config.FuncGetWidth == nil {
+		return 0
+	}
+	if w := r.rl.Config.FuncGetWidth(); w > 0 {
+		return w
+	}
+	return 0
+}
+
+// Height 返回终端高度（行数），非交互式输入或无法获取时（如 SSH session）返回 0
+func (r *Reader) Height() int {
+	if r.rl == nil {
+		return 0
+	}
+	f, ok := r.rwc.ReadWriter.(interface{ Fd() uintptr })
+	if !ok {
+		return 0
+	}
+	if _, h, err := readline.GetSize(int(f.Fd())); err == nil && h > 0 {
+		return h
+	}
+	return 0
 }
 
 // SetPrompt 设置提示符
 func (r *Reader) SetPrompt(prompt string) {
-	r.rl.SetPrompt(prompt)
+	r.prompt = prompt
+	if r.rl != nil {
+		r.rl.SetPrompt(prompt)
+	}
+}
+
+// SetTranscript 设置会话记录的写入目标，nil 表示停止记录
+// 非交互输入不回显，因此不记录
+func (r *Reader) SetTranscript(w io.Writer) {
+	r.transcript = w
 }
 
 // Close 关闭读取器
 func (r *Reader) Close() error {
-	return r.rl.Close()
+	if r.rl != nil {
+		return r.rl.Close()
+	}
+	return nil
 }
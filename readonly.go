@@ -0,0 +1,107 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// setReadOnly 只读模式下将会话的默认事务设为只读，由服务器兜底拒绝写操作
+func setReadOnly(ctx context.Context, conn *sql.Conn) error {
+	_, err := conn.ExecContext(ctx, "SET SESSION CHARACTERISTICS AS TRANSACTION READ ONLY")
+	return err
+}
+
+// setConfigCall 匹配 set_config 调用及其以字符串常量给出的设置名
+var setConfigCall = regexp.MustCompile(`(?i)\bset_config\s*\(\s*(?:'([^']*)'\s*,)?`)
+
+// changesReadOnly 判断语句中的 set_config 调用是否可能修改只读设置：
+// 设置名不是字符串常量（如拼接或变量）时无法确定，同样视为修改
+func changesReadOnly(sqlStr string) bool {
+	for _, m := range setConfigCall.FindAllStringSubmatch(sqlStr, -1) {
+		name := strings.ToLower(strings.TrimSpace(m[1]))
+		if name == "" || strings.HasSuffix(name, "transaction_read_only") {
+			return true
+		}
+	}
+	return false
+}
+
+// writeStatement 判断语句是否为写操作（或会解除只读设置），返回语句关键字
+// 按顶层关键字判断，无法确定的语句（如 EXECUTE、数据修改型 CTE 嵌套在括号内）交由服务器的只读事务拒绝；
+// 只读事务不阻止 set_config 关闭 default_transaction_read_only，因此任何位置的此类调用都直接拒绝
+func writeStatement(sqlStr string) (string, bool) {
+	words := topLevelWords(stripLeadingComments(sqlStr))
+	if len(words) == 0 {
+		return "", false
+	}
+	if changesReadOnly(sqlStr) {
+		return "set_config", true
+	}
+
+	keyword := words[0]
+	switch keyword {
+	case "COPY":
+		// COPY ... TO 只读取数据（TO PROGRAM 会在服务器上运行程序），COPY ... FROM 写入表
+		if containsWord(words, "TO") && !containsWord(words, "PROGRAM") {
+			return keyword, false
+		}
+		return keyword, true
+	case "SELECT":
+		// SELECT ... INTO 会创建新表
+		if containsWord(words, "INTO") {
+			return "SELECT INTO", true
+		}
+		return keyword, false
+	case "WITH":
+		switch main := cteMainKeyword(words[1:]); main {
+		case "INSERT", "UPDATE", "DELETE", "MERGE":
+			return main, true
+		}
+		return keyword, false
+	case "EXPLAIN":
+		// EXPLAIN ANALYZE 会真正执行语句
+		if !strings.Contains(strings.ToUpper(sqlStr), "ANALYZE") {
+			return keyword, false
+		}
+		for _, w := range words[1:] {
+			switch w {
+			case "INSERT", "UPDATE", "DELETE", "MERGE", "CREATE":
+				return "EXPLAIN ANALYZE " + w, true
+			}
+		}
+		return keyword, false
+	case "BEGIN", "START", "SET":
+		// BEGIN READ WRITE、SET TRANSACTION READ WRITE、SET default_transaction_read_only 等会解除只读
+		if containsWord(words, "WRITE") || containsWord(words, "DEFAULT_TRANSACTION_READ_ONLY") ||
+			containsWord(words, "TRANSACTION_READ_ONLY") {
+			return keyword, true
+		}
+		return keyword, false
+	case "RESET":
+		if containsWord(words, "ALL") || containsWord(words, "DEFAULT_TRANSACTION_READ_ONLY") ||
+			containsWord(words, "TRANSACTION_READ_ONLY") {
+			return keyword, true
+		}
+		return keyword, false
+	case "DISCARD":
+		return keyword, containsWord(words, "ALL")
+	case "SHOW", "TABLE", "VALUES", "COMMIT", "END", "ROLLBACK", "ABORT", "SAVEPOINT", "RELEASE",
+		"DECLARE", "FETCH", "MOVE", "CLOSE", "PREPARE", "EXECUTE", "DEALLOCATE", "LISTEN", "UNLISTEN":
+		return keyword, false
+	}
+	return keyword, true
+}
+
+// checkReadOnly 只读模式下拒绝写操作
+func (c *CLI) checkReadOnly(sqlStr string) error {
+	if !c.config.ReadOnly {
+		return nil
+	}
+	if keyword, ok := writeStatement(sqlStr); ok {
+		return fmt.Errorf("%s is not allowed in read-only mode", keyword)
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package postgres
+
+import "testing"
+
+func TestWriteStatement(t *testing.T) {
+	tests := []struct {
+		sql     string
+		keyword string
+		write   bool
+	}{
+		{"SELECT * FROM t", "SELECT", false},
+		{"SELECT * INTO u FROM t", "SELECT INTO", true},
+		{"INSERT INTO t VALUES (1)", "INSERT", true},
+		{"SET default_transaction_read_only = off", "SET", true},
+		{"SET search_path = app", "SET", false},
+		{"SELECT set_config('default_transaction_read_only', 'off', false)", "set_config", true},
+		{"select pg_catalog.SET_CONFIG('transaction_read_only','off',true)", "set_config", true},
+		{"SELECT set_config('default_transaction_' || 'read_only', 'off', false)", "set_config", true},
+		{"SELECT 1 FROM t WHERE set_config(current_setting('app.name'), 'off', false) IS NOT NULL", "set_config", true},
+		{"SELECT set_config('search_path', 'app', false)", "SELECT", false},
+		{"COPY t TO STDOUT", "COPY", false},
+		{"COPY (SELECT * FROM t) TO STDOUT WITH (FORMAT csv)", "COPY", false},
+		{"COPY t FROM STDIN", "COPY", true},
+		{"COPY t TO PROGRAM 'gzip > /tmp/t.gz'", "COPY", true},
+	}
+	for _, tt := range tests {
+		keyword, write := writeStatement(tt.sql)
+		if keyword != tt.keyword || write != tt.write {
+			t.Errorf("writeStatement(%q) = %q, %v, want %q, %v", tt.sql, keyword, write, tt.keyword, tt.write)
+		}
+	}
+}
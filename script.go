@@ -0,0 +1,398 @@
+package postgres
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// errQuit 表示用户请求退出（\q、exit、quit）
+var errQuit = errors.New("quit")
+
+// handleScriptCommand 处理变量与脚本相关命令，返回是否已处理
+func (c *CLI) handleScriptCommand(cmd string) (bool, error) {
+	parts := strings.Fields(cmd)
+	if len(parts) == 0 {
+		return false, nil
+	}
+
+	switch parts[0] {
+	case "\\set":
+		// 值可用单引号括起以保留空格，如 \set PROMPT1 '%n@%/%# '
+		c.setVariable(parseArgs(strings.TrimSpace(strings.TrimPrefix(cmd, "\\set"))))
+		return true, nil
+	case "\\unset":
+		if len(parts) < 2 {
+			fmt.Fprintf(c.term, "\\unset: missing required argument\n")
+			return true, nil
+		}
+		delete(c.vars, parts[1])
+		return true, nil
+	case "\\setenv":
+		c.setEnv(parts[1:])
+		return true, nil
+	case "\\prompt":
+		c.promptVariable(parseArgs(strings.TrimSpace(strings.TrimPrefix(cmd, "\\prompt"))))
+		return true, nil
+	case "\\s":
+		c.showHistory(strings.TrimSpace(strings.TrimPrefix(cmd, "\\s")))
+		return true, nil
+	case "\\i", "\\include":
+		if len(parts) < 2 {
+			fmt.Fprintf(c.term, "\\i: missing required argument\n")
+			return true, nil
+		}
+		return true, c.includeFile(parts[1])
+	case "\\script":
+		c.handleScript(parseArgs(strings.TrimSpace(strings.TrimPrefix(cmd, "\\script"))))
+		return true, nil
+	case "\\waitfor":
+		return true, c.waitfor(strings.TrimPrefix(cmd, parts[0]))
+	case "\\o", "\\out":
+		c.setOutput(strings.TrimSpace(strings.TrimPrefix(cmd, parts[0])))
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// setVariable 处理 \set：无参数时列出所有变量，否则设置变量值
+func (c *CLI) setVariable(args []string) {
+	if len(args) == 0 {
+		names := make([]string, 0, len(c.vars))
+		for name := range c.vars {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(c.term, "%s = '%s'\n", name, c.vars[name])
+		}
+		return
+	}
+	name, value := args[0], strings.Join(args[1:], "")
+
+	// 部分变量直接控制 CLI 行为，值无效时变量保持不变
+	var err error
+	switch name {
+	case "maxrows":
+		err = c.settings.Set("maxrows", value)
+	case "STATEMENT_TIMEOUT":
+		err = c.setStatementTimeout(value)
+	}
+	if err != nil {
+		fmt.Fprintf(c.term, "\\set: %v\n", err)
+		return
+	}
+	c.vars[name] = value
+}
+
+// setEnv 处理 \setenv NAME [VALUE]：设置或删除（无 VALUE 时）进程环境变量
+// 注意环境变量是进程级的，嵌入使用时会影响同一进程内的其他代码
+func (c *CLI) setEnv(args []string) {
+	if len(args) == 0 {
+		fmt.Fprintf(c.term, "\\setenv: missing required argument\n")
+		return
+	}
+
+	var err error
+	if len(args) == 1 {
+		err = os.Unsetenv(args[0])
+	} else {
+		err = os.Setenv(args[0], strings.Join(args[1:], " "))
+	}
+	if err != nil {
+		fmt.Fprintf(c.term, "\\setenv: %v\n", err)
+	}
+}
+
+// promptVariable 处理 \prompt [-p] [text] name：显示提示文本并读取一行到变量
+// -p 表示以掩码方式输入；读到 EOF 时不设置变量
+func (c *CLI) promptVariable(args []string) {
+	masked := false
+	if len(args) > 0 && args[0] == "-p" {
+		masked = true
+		args = args[1:]
+	}
+	if len(args) == 0 {
+		fmt.Fprintf(c.term, "\\prompt: missing required argument\n")
+		return
+	}
+
+	name := args[len(args)-1]
+	text := strings.Join(args[:len(args)-1], " ")
+
+	var value string
+	var err error
+	if masked {
+		value, err = c.reader.ReadPassword(text)
+	} else {
+		if !c.reader.Interactive() {
+			fmt.Fprintf(c.term, "%s", text)
+		}
+		c.reader.SetPrompt(text)
+		value, err = c.reader.ReadLine()
+	}
+	if err != nil {
+		return
+	}
+	c.vars[name] = value
+}
+
+// parseArgs 按空白拆分命令参数，单引号括起的部分作为一个参数（” 表示一个单引号）
+func parseArgs(s string) []string {
+	var args []string
+	var cur strings.Builder
+	inQuote, hasArg := false, false
+
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		switch {
+		case inQuote && ch == '\'' && i+1 < len(s) && s[i+1] == '\'':
+			cur.WriteByte('\'')
+			i++
+		case ch == '\'':
+			inQuote = !inQuote
+			hasArg = true
+		case !inQuote && (ch == ' ' || ch == '\t'):
+			if hasArg {
+				args = append(args, cur.String())
+				cur.Reset()
+				hasArg = false
+			}
+		default:
+			cur.WriteByte(ch)
+			hasArg = true
+		}
+	}
+	if hasArg {
+		args = append(args, cur.String())
+	}
+	return args
+}
+
+// interpolate 替换 SQL 中的变量引用：:name 原样替换，:'name' 作为字符串常量，:"name" 作为标识符
+// 字符串、引号标识符、注释与 dollar 引用（如函数体）中的内容、:: 类型转换以及未定义的变量保持不变
+func (c *CLI) interpolate(sql string) string {
+	if len(c.vars) == 0 || !strings.Contains(sql, ":") {
+		return sql
+	}
+
+	var out strings.Builder
+	s := &sqlScanner{sql: sql}
+	for s.pos < len(sql) {
+		start := s.pos
+		if s.skipQuoted() {
+			out.WriteString(sql[start:s.pos])
+			continue
+		}
+		i := s.pos
+		s.pos++
+		ch := sql[i]
+		if ch != ':' || i+1 >= len(sql) {
+			out.WriteByte(ch)
+			continue
+		}
+
+		next := sql[i+1]
+		switch {
+		case next == ':':
+			out.WriteString("::")
+			s.pos = i + 2
+		case next == '\'' || next == '"':
+			end := strings.IndexByte(sql[i+2:], next)
+			if end < 0 {
+				out.WriteByte(ch)
+				continue
+			}
+			name := sql[i+2 : i+2+end]
+			value, ok := c.vars[name]
+			if !ok {
+				out.WriteByte(ch)
+				continue
+			}
+			if next == '\'' {
+				out.WriteString(pq.QuoteLiteral(value))
+			} else {
+				out.WriteString(pq.QuoteIdentifier(value))
+			}
+			s.pos = i + 3 + end
+		case isIdentChar(next):
+			end := i + 1
+			for end < len(sql) && isIdentChar(sql[end]) {
+				end++
+			}
+			value, ok := c.vars[sql[i+1:end]]
+			if !ok {
+				out.WriteByte(ch)
+				continue
+			}
+			out.WriteString(value)
+			s.pos = end
+		default:
+			out.WriteByte(ch)
+		}
+	}
+	return out.String()
+}
+
+// isIdentChar 是否为变量名允许的字符
+func isIdentChar(ch byte) bool {
+	return ch == '_' || ch >= 'a' && ch <= 'z' || ch >= 'A' && ch <= 'Z' || ch >= '0' && ch <= '9'
+}
+
+// boolVar 以布尔值读取变量（on/true/yes/1 为真）
+func (c *CLI) boolVar(name string) bool {
+	switch strings.ToLower(c.vars[name]) {
+	case "on", "true", "yes", "1":
+		return true
+	}
+	return false
+}
+
+// includeFile 执行 SQL 文件（\i）
+func (c *CLI) includeFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		fmt.Fprintf(c.term, "%s: %v\n", path, err)
+		return err
+	}
+	defer f.Close()
+
+	stats := &scriptStats{}
+	run := func() error {
+		return c.runScript(f, path, stats)
+	}
+	if c.config.SingleTransaction || c.boolVar("SINGLE_TRANSACTION") {
+		err = c.runSingleTransaction(run)
+	} else {
+		err = run()
+	}
+
+	if c.settings.Timing {
+		stats.print(c, path)
+	}
+	return err
+}
+
+// scriptStats 脚本执行的耗时统计
+type scriptStats struct {
+	count       int
+	total       time.Duration
+	slowest     time.Duration
+	slowestStmt string
+}
+
+// record 记录一条语句的耗时
+func (s *scriptStats) record(stmt string, elapsed time.Duration) {
+	s.count++
+	s.total += elapsed
+	if s.count == 1 || elapsed > s.slowest {
+		s.slowest = elapsed
+		s.slowestStmt = stmt
+	}
+}
+
+// print 输出统计摘要：语句总数、总耗时与最慢的语句
+func (s *scriptStats) print(c *CLI, name string) {
+	fmt.Fprintf(c.term, "Summary for %s: %d statements, total time: %s\n", name, s.count, formatDuration(s.total))
+	if s.count > 0 {
+		stmt := strings.Join(strings.Fields(s.slowestStmt), " ")
+		// 按显示宽度截断，不拆分多字节字符
+		stmt = truncateCell(stmt, 60)
+		fmt.Fprintf(c.term, "Slowest: %s  %s\n", formatDuration(s.slowest), stmt)
+	}
+	fmt.Fprintf(c.term, "\n")
+}
+
+// runSingleTransaction 在单个事务中执行 run：成功则 COMMIT，出错或有语句失败则 ROLLBACK
+// 已处于事务中时直接执行，不再嵌套
+func (c *CLI) runSingleTransaction(run func() error) error {
+	if c.inTransaction {
+		return run()
+	}
+
+	ctx := context.Background()
+	if _, err := c.conn.ExecContext(ctx, "BEGIN"); err != nil {
+		c.printError(err)
+		return err
+	}
+	c.inTransaction, c.singleTxn, c.singleTxnFailed = true, true, false
+
+	err := run()
+
+	end := "COMMIT"
+	if err != nil && err != errQuit || c.singleTxnFailed {
+		end = "ROLLBACK"
+		if err == nil {
+			err = errors.New("statement failed in single-transaction mode, transaction rolled back")
+		}
+	}
+	c.inTransaction, c.singleTxn = false, false
+
+	if _, endErr := c.conn.ExecContext(ctx, end); endErr != nil {
+		c.printError(endErr)
+		if err == nil {
+			err = endErr
+		}
+	} else if end == "ROLLBACK" {
+		fmt.Fprintf(c.term, "ROLLBACK\n")
+	}
+	return err
+}
+
+// runScript 逐条执行脚本中的语句
+// 语句失败时若 ON_ERROR_STOP 已开启，即中止剩余语句并返回该错误；stats 不为 nil 时记录每条语句的耗时
+func (c *CLI) runScript(r io.Reader, name string, stats *scriptStats) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	// \copy ... from stdin 与 psql 一致读取脚本中随后的数据行；嵌套的 \i 结束后恢复外层脚本
+	prevInput := c.scriptInput
+	c.scriptInput = scanner
+	defer func() { c.scriptInput = prevInput }()
+
+	var buf queryBuffer
+	for scanner.Scan() {
+		stmt, done := buf.add(scanner.Text())
+		if !done || stmt == "" {
+			continue
+		}
+		if err := c.runScriptStatement(stmt, stats); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		fmt.Fprintf(c.term, "%s: %v\n", name, err)
+		return err
+	}
+
+	// 文件末尾未以分号结束的语句同样执行
+	if !buf.empty() {
+		return c.runScriptStatement(buf.flush(), stats)
+	}
+	return nil
+}
+
+// runScriptStatement 执行脚本中的一条语句，仅在需要中止脚本时返回错误
+// ON_ERROR_STOP 在每次失败后读取，脚本自身开头的 \set ON_ERROR_STOP on 同样生效
+func (c *CLI) runScriptStatement(stmt string, stats *scriptStats) error {
+	start := time.Now()
+	err := c.runStatement(stmt)
+	if stats != nil {
+		stats.record(stmt, time.Since(start))
+	}
+	if err == nil {
+		return nil
+	}
+	if err == errQuit || c.boolVar("ON_ERROR_STOP") {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package postgres
+
+import (
+	"bytes"
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// testTerminal 以内存缓冲区代替终端
+type testTerminal struct {
+	bytes.Buffer
+}
+
+// newTestCLI 返回不连接服务器的 CLI；db 只用于让 RunFile 等入口认为已连接，测试的脚本不会执行 SQL
+func newTestCLI(t *testing.T) (*CLI, *testTerminal) {
+	t.Helper()
+	term := &testTerminal{}
+	c := NewCLIWithConfig(term, &Config{})
+	db, err := sql.Open("postgres", "host=localhost")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	c.db = db
+	return c, term
+}
+
+// failingScript 先开启 ON_ERROR_STOP，随后的 \i 因文件不存在而失败，之后的 \set 不应执行
+func failingScript(t *testing.T, onErrorStop string) string {
+	t.Helper()
+	missing := filepath.Join(t.TempDir(), "missing.sql")
+	return strings.Join([]string{
+		`\set ON_ERROR_STOP ` + onErrorStop,
+		`\i ` + missing,
+		`\set after reached`,
+	}, "\n") + "\n"
+}
+
+func TestRunScriptOnErrorStopSetByScript(t *testing.T) {
+	tests := []struct {
+		onErrorStop string
+		stop        bool
+	}{
+		{"on", true},
+		{"off", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.onErrorStop, func(t *testing.T) {
+			c, _ := newTestCLI(t)
+			err := c.runScript(strings.NewReader(failingScript(t, tt.onErrorStop)), "test", nil)
+			if (err != nil) != tt.stop {
+				t.Errorf("runScript error = %v, want error: %v", err, tt.stop)
+			}
+			if _, reached := c.vars["after"]; reached == tt.stop {
+				t.Errorf("statement after the failure ran = %v, want %v", reached, !tt.stop)
+			}
+		})
+	}
+}
@@ -0,0 +1,411 @@
+package postgres
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// Settings 输出格式与行为选项，由 \pset、\x、\timing、\C 与 \set maxrows 修改
+type Settings struct {
+	Expanded      bool           // expanded：扩展显示模式（\x）
+	Timing        bool           // timing：显示执行耗时（\timing）
+	TimingDetail  bool           // timing detail：拆分执行与渲染耗时
+	MaxRows       int            // maxrows：最大显示行数，0 表示不限制
+	Border        int            // border：表格边框样式 0、1、2
+	Title         string         // title：结果上方的标题（\C）
+	NumericLocale bool           // numericlocale：数值按区域设置的分隔符千位分组显示
+	PrettyArrays  bool           // arrays：pretty 美化数组与复合类型，raw 原样显示
+	ByteaLength   bool           // bytea：length 只显示长度，hex 显示十六进制
+	Pager         bool           // pager：交互模式下查询结果满一屏时暂停（内置分页器）
+	Binary        bool           // binary：以预备语句执行查询，整数、bytea 与 uuid 列以二进制格式接收
+	TimeFormat    string         // timeformat：时间戳的 Go 时间布局，空为默认格式
+	Null          string         // null：NULL 的显示文本，默认空串
+	TuplesOnly    bool           // tuples_only：只输出数据行，不输出列名、标题与行数
+	Fields        []string       // fields：扩展模式下只显示的列，空为全部
+	Columns       int            // columns：扩展模式折行的目标宽度，0 表示不折行，ColumnsAuto 表示使用终端宽度
+	ColumnWidths  map[string]int // colwidth：按列名（小写）覆盖表格单元格的最大显示宽度，0 表示不截断
+	Format        string         // format：aligned 表格，unaligned 以 fieldsep 分隔，csv 逗号分隔值，insert INSERT 语句，template 以 \set templatefile 的 Go 模板输出
+	FieldSep      string         // fieldsep：unaligned 格式的字段分隔符，默认 |
+	InsertTable   string         // format insert 的目标表，空为从查询推断
+	CSVFieldSep   string         // csv_fieldsep：CSV 的字段分隔符，默认逗号
+	CSVBOM        bool           // csv_bom：CSV 输出到文件（\o）时在文件开头写入 UTF-8 BOM
+}
+
+// ColumnsAuto Settings.Columns 的取值（\pset columns auto）：按终端宽度折行
+const ColumnsAuto = -1
+
+// DefaultSettings 返回默认选项
+func DefaultSettings() Settings {
+	return Settings{MaxRows: 1000, Border: 1, Columns: ColumnsAuto, Format: "aligned", FieldSep: "|", CSVFieldSep: ","}
+}
+
+// settingNames 支持的选项名（按字母顺序）
+var settingNames = []string{
+	"arrays", "binary", "border", "bytea", "columns", "colwidth", "csv_bom", "csv_fieldsep", "expanded", "fields",
+	"fieldsep", "format", "maxrows", "null", "numericlocale", "pager", "timeformat", "timing", "title", "tuples_only",
+}
+
+// Settings 返回会话的输出格式与行为选项，可在嵌入使用时直接读取或修改
+func (c *CLI) Settings() *Settings {
+	return &c.settings
+}
+
+// Names 返回所有选项名（按字母顺序）
+func (s *Settings) Names() []string {
+	return append([]string(nil), settingNames...)
+}
+
+// Get 返回选项的当前值
+func (s *Settings) Get(name string) (string, error) {
+	switch name {
+	case "arrays":
+		if s.PrettyArrays {
+			return "pretty", nil
+		}
+		return "raw", nil
+	case "binary":
+		return onOff(s.Binary), nil
+	case "border":
+		return strconv.Itoa(s.Border), nil
+	case "bytea":
+		if s.ByteaLength {
+			return "length", nil
+		}
+		return "hex", nil
+	case "columns":
+		if s.Columns == ColumnsAuto {
+			return "auto", nil
+		}
+		return strconv.Itoa(s.Columns), nil
+	case "colwidth":
+		names := make([]string, 0, len(s.ColumnWidths))
+		for name := range s.ColumnWidths {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		entries := make([]string, len(names))
+		for i, name := range names {
+			entries[i] = fmt.Sprintf("%s=%d", name, s.ColumnWidths[name])
+		}
+		return strings.Join(entries, ","), nil
+	case "csv_bom":
+		return onOff(s.CSVBOM), nil
+	case "csv_fieldsep":
+		return s.CSVFieldSep, nil
+	case "expanded":
+		return onOff(s.Expanded), nil
+	case "fields":
+		return strings.Join(s.Fields, ","), nil
+	case "fieldsep":
+		return s.FieldSep, nil
+	case "format":
+		if s.Format == "insert" && s.InsertTable != "" {
+			return "insert " + s.InsertTable, nil
+		}
+		return s.Format, nil
+	case "maxrows":
+		return strconv.Itoa(s.MaxRows), nil
+	case "null":
+		return s.Null, nil
+	case "numericlocale":
+		return onOff(s.NumericLocale), nil
+	case "pager":
+		return onOff(s.Pager), nil
+	case "timeformat":
+		return s.TimeFormat, nil
+	case "timing":
+		if s.TimingDetail {
+			return "detail", nil
+		}
+		return onOff(s.Timing), nil
+	case "title":
+		return s.Title, nil
+	case "tuples_only":
+		return onOff(s.TuplesOnly), nil
+	}
+	return "", fmt.Errorf("unknown option: %s", name)
+}
+
+// Set 设置选项；布尔选项的 value 为空时切换当前状态
+func (s *Settings) Set(name, value string) error {
+	switch name {
+	case "arrays":
+		switch value {
+		case "pretty":
+			s.PrettyArrays = true
+		case "raw":
+			s.PrettyArrays = false
+		default:
+			return fmt.Errorf("arrays must be pretty or raw")
+		}
+	case "binary":
+		on, ok := parseToggle(value, s.Binary)
+		if !ok {
+			return fmt.Errorf("binary must be on or off")
+		}
+		s.Binary = on
+	case "border":
+		border, err := strconv.Atoi(value)
+		if err != nil || border < 0 || border > 2 {
+			return fmt.Errorf("border must be 0, 1 or 2")
+		}
+		s.Border = border
+	case "bytea":
+		switch value {
+		case "hex":
+			s.ByteaLength = false
+		case "length":
+			s.ByteaLength = true
+		default:
+			return fmt.Errorf("bytea must be hex or length")
+		}
+	case "columns":
+		if strings.EqualFold(value, "auto") {
+			s.Columns = ColumnsAuto
+			break
+		}
+		width, err := strconv.Atoi(value)
+		if err != nil || width < 0 {
+			return fmt.Errorf("columns must be auto or a non-negative integer (0 disables wrapping)")
+		}
+		s.Columns = width
+	case "colwidth":
+		// 以逗号或空白分隔的 NAME=WIDTH，替换之前的全部设置
+		widths := map[string]int{}
+		for _, entry := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' }) {
+			name, w, ok := strings.Cut(entry, "=")
+			width, err := strconv.Atoi(w)
+			if !ok || name == "" || err != nil || width < 0 {
+				return fmt.Errorf("colwidth entries must be NAME=WIDTH with a non-negative width (0 shows the column in full)")
+			}
+			widths[strings.ToLower(name)] = width
+		}
+		s.ColumnWidths = nil
+		if len(widths) > 0 {
+			s.ColumnWidths = widths
+		}
+	case "csv_bom":
+		on, ok := parseToggle(value, s.CSVBOM)
+		if !ok {
+			return fmt.Errorf("csv_bom must be on or off")
+		}
+		s.CSVBOM = on
+	case "csv_fieldsep":
+		// tab 与 \t 表示制表符，便于在命令行中输入
+		if value == "tab" || value == "\\t" {
+			value = "\t"
+		}
+		if len(value) != 1 || value == "\"" || value == "\n" || value == "\r" {
+			return fmt.Errorf("csv_fieldsep must be a single one-byte character other than a quote or newline")
+		}
+		s.CSVFieldSep = value
+	case "expanded":
+		on, ok := parseToggle(value, s.Expanded)
+		if !ok {
+			return fmt.Errorf("expanded must be on or off")
+		}
+		s.Expanded = on
+	case "fields":
+		// 以逗号或空白分隔的列名
+		s.Fields = nil
+		for _, field := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' }) {
+			s.Fields = append(s.Fields, field)
+		}
+	case "fieldsep":
+		// tab 与 \t 表示制表符，与 csv_fieldsep 一致
+		if value == "tab" || value == "\\t" {
+			value = "\t"
+		}
+		if value == "" || strings.ContainsAny(value, "\r\n") {
+			return fmt.Errorf("fieldsep must be a non-empty string without newlines")
+		}
+		s.FieldSep = value
+	case "format":
+		// insert 可以带目标表名：\pset format insert TABLE
+		format, table, _ := strings.Cut(strings.TrimSpace(value), " ")
+		switch format {
+		case "aligned", "unaligned", "csv", "insert", "template":
+			if table != "" && format != "insert" {
+				return fmt.Errorf("only the insert format takes a table name")
+			}
+			s.Format, s.InsertTable = format, strings.TrimSpace(table)
+		default:
+			return fmt.Errorf("allowed formats are aligned, unaligned, csv, insert, template")
+		}
+	case "maxrows":
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return fmt.Errorf("maxrows must be a non-negative integer (0 means unlimited)")
+		}
+		s.MaxRows = n
+	case "null":
+		s.Null = value
+	case "numericlocale":
+		on, ok := parseToggle(value, s.NumericLocale)
+		if !ok {
+			return fmt.Errorf("numericlocale must be on or off")
+		}
+		s.NumericLocale = on
+	case "pager":
+		on, ok := parseToggle(value, s.Pager)
+		if !ok {
+			return fmt.Errorf("pager must be on or off")
+		}
+		s.Pager = on
+	case "timeformat":
+		switch value {
+		case "", "default":
+			s.TimeFormat = ""
+		default:
+			s.TimeFormat = value
+		}
+	case "timing":
+		switch strings.ToLower(value) {
+		case "":
+			s.Timing, s.TimingDetail = !s.Timing, false
+		case "detail":
+			s.Timing, s.TimingDetail = true, true
+		default:
+			on, ok := parseToggle(value, s.Timing)
+			if !ok {
+				return fmt.Errorf("unrecognized value \"%s\": on, off or detail expected", value)
+			}
+			s.Timing, s.TimingDetail = on, false
+		}
+	case "title":
+		s.Title = value
+	case "tuples_only":
+		on, ok := parseToggle(value, s.TuplesOnly)
+		if !ok {
+			return fmt.Errorf("tuples_only must be on or off")
+		}
+		s.TuplesOnly = on
+	default:
+		return fmt.Errorf("unknown option: %s", name)
+	}
+	return nil
+}
+
+// Describe 以 psql 的措辞描述选项的当前值，如 "Border style is 1."
+func (s *Settings) Describe(name string) string {
+	switch name {
+	case "arrays":
+		value, _ := s.Get(name)
+		return fmt.Sprintf("Array display is %s.", value)
+	case "binary":
+		return fmt.Sprintf("Binary result format is %s.", onOff(s.Binary))
+	case "border":
+		return fmt.Sprintf("Border style is %d.", s.Border)
+	case "bytea":
+		value, _ := s.Get(name)
+		return fmt.Sprintf("Bytea display is %s.", value)
+	case "columns":
+		switch s.Columns {
+		case ColumnsAuto:
+			return "Target width is the terminal width."
+		case 0:
+			return "Target width is unset (no wrapping)."
+		}
+		return fmt.Sprintf("Target width is %d.", s.Columns)
+	case "colwidth":
+		if len(s.ColumnWidths) == 0 {
+			return fmt.Sprintf("Column widths are limited to %d.", maxCellWidth)
+		}
+		value, _ := s.Get(name)
+		return fmt.Sprintf("Column widths are limited to %d, except %s.", maxCellWidth, strings.ReplaceAll(value, ",", ", "))
+	case "csv_bom":
+		return fmt.Sprintf("CSV byte order mark is %s.", onOff(s.CSVBOM))
+	case "csv_fieldsep":
+		return fmt.Sprintf("Field separator for CSV is \"%s\".", s.CSVFieldSep)
+	case "expanded":
+		return fmt.Sprintf("Expanded display is %s.", onOff(s.Expanded))
+	case "fields":
+		if len(s.Fields) == 0 {
+			return "Expanded display shows all fields."
+		}
+		return fmt.Sprintf("Expanded display shows fields: %s.", strings.Join(s.Fields, ", "))
+	case "fieldsep":
+		return fmt.Sprintf("Field separator is \"%s\".", s.FieldSep)
+	case "format":
+		value, _ := s.Get(name)
+		return fmt.Sprintf("Output format is %s.", value)
+	case "maxrows":
+		return fmt.Sprintf("Row limit is %d.", s.MaxRows)
+	case "null":
+		return fmt.Sprintf("Null display is \"%s\".", s.Null)
+	case "numericlocale":
+		return fmt.Sprintf("Locale-adjusted numeric output is %s.", onOff(s.NumericLocale))
+	case "pager":
+		if s.Pager {
+			return "Pager is used for long output."
+		}
+		return "Pager usage is off."
+	case "timeformat":
+		if s.TimeFormat == "" {
+			return "Timestamp format is default."
+		}
+		return fmt.Sprintf("Timestamp format is \"%s\".", s.TimeFormat)
+	case "timing":
+		switch {
+		case s.TimingDetail:
+			return "Timing is on (execution and rendering shown separately)."
+		case s.Timing:
+			return "Timing is on."
+		}
+		return "Timing is off."
+	case "title":
+		if s.Title == "" {
+			return "Title is unset."
+		}
+		return fmt.Sprintf("Title is \"%s\".", s.Title)
+	case "tuples_only":
+		if s.TuplesOnly {
+			return "Tuples only is on."
+		}
+		return "Tuples only is off."
+	}
+	return ""
+}
+
+// String 按名称顺序列出所有选项，字符串值以单引号括起（\pset 不带参数）
+func (s *Settings) String() string {
+	var sb strings.Builder
+	for _, name := range settingNames {
+		value, _ := s.Get(name)
+		switch name {
+		case "colwidth", "csv_fieldsep", "fields", "fieldsep", "null", "timeformat", "title":
+			value = pq.QuoteLiteral(value)
+		}
+		fmt.Fprintf(&sb, "%-16s %s\n", name, value)
+	}
+	return sb.String()
+}
+
+// onOff 将布尔值显示为 on/off
+func onOff(b bool) string {
+	if b {
+		return "on"
+	}
+	return "off"
+}
+
+// columnWidth 返回表格中该列单元格的最大显示宽度：colwidth 中的设置优先，否则为 maxCellWidth；0 表示不截断
+func (s *Settings) columnWidth(col string) int {
+	if width, ok := s.ColumnWidths[strings.ToLower(col)]; ok {
+		return width
+	}
+	return maxCellWidth
+}
+
+// setWithoutValue 判断选项在不给出值时是否仍然修改：布尔选项切换状态，title 与 fields 被清除
+func setWithoutValue(name string) bool {
+	switch name {
+	case "binary", "colwidth", "csv_bom", "expanded", "numericlocale", "pager", "timing", "title", "fields", "tuples_only":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,44 @@
+package postgres
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSettingsListing(t *testing.T) {
+	s := DefaultSettings()
+	listing := s.String()
+	for _, name := range []string{"null", "fieldsep", "tuples_only", "format", "border", "expanded", "pager"} {
+		if !strings.Contains(listing, name+" ") {
+			t.Errorf("\\pset listing does not include %s:\n%s", name, listing)
+		}
+	}
+}
+
+func TestSettingsSet(t *testing.T) {
+	tests := []struct {
+		name, value string
+		want        string
+		wantErr     bool
+	}{
+		{"null", "(null)", "(null)", false},
+		{"fieldsep", ";", ";", false},
+		{"fieldsep", "tab", "\t", false},
+		{"fieldsep", "", "|", true},
+		{"tuples_only", "on", "on", false},
+		{"tuples_only", "", "on", false},
+		{"tuples_only", "maybe", "off", true},
+		{"format", "unaligned", "unaligned", false},
+		{"format", "wrapped", "aligned", true},
+	}
+	for _, tt := range tests {
+		s := DefaultSettings()
+		err := s.Set(tt.name, tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Set(%q, %q) error = %v, want error: %v", tt.name, tt.value, err, tt.wantErr)
+		}
+		if got, _ := s.Get(tt.name); got != tt.want {
+			t.Errorf("after Set(%q, %q), Get = %q, want %q", tt.name, tt.value, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package postgres
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/chzyer/readline"
+)
+
+// tableOptions renderTable 的选项
+type tableOptions struct {
+	minColWidth int // 列的最小宽度
+	maxColWidth int // 单元格最大显示宽度，超出时截断并以 ... 结尾；0 表示不限制
+}
+
+// renderTable 计算列宽并按当前边框样式与标题打印表格，所有表格输出（查询结果、\d 等）共用
+// 列宽按显示宽度计算：忽略 ANSI 样式，东亚宽字符计为 2
+func (c *CLI) renderTable(cols []string, rows [][]string, opts tableOptions) {
+	colWidths := make([]int, len(cols))
+	for i, col := range cols {
+		colWidths[i] = displayWidth(col)
+		if colWidths[i] < opts.minColWidth {
+			colWidths[i] = opts.minColWidth
+		}
+	}
+	for _, row := range rows {
+		for i := range row {
+			if opts.maxColWidth > 0 {
+				row[i] = truncateCell(row[i], opts.maxColWidth)
+			}
+			if w := displayWidth(row[i]); w > colWidths[i] {
+				colWidths[i] = w
+			}
+		}
+	}
+	c.printTable(cols, colWidths, rows)
+}
+
+// displayWidth 返回文本在终端中的显示宽度
+func displayWidth(s string) int {
+	r := readline.Runes{}
+	return r.WidthAll(r.ColorFilter([]rune(s)))
+}
+
+// truncateCell 将超过 maxWidth 显示宽度的文本截断为 maxWidth，以 ... 结尾
+func truncateCell(s string, maxWidth int) string {
+	if displayWidth(s) <= maxWidth {
+		return s
+	}
+	r := readline.Runes{}
+	width := 0
+	for i, ch := range s {
+		width += r.Width(ch)
+		if width > maxWidth-3 {
+			return s[:i] + "..."
+		}
+	}
+	return s
+}
+
+// wrapText 按换行符拆分文本，并将超过 width 显示宽度的行折成多行；width 小于 10 时只按换行符拆分
+func wrapText(s string, width int) []string {
+	lines := strings.Split(s, "\n")
+	if width < 10 {
+		return lines
+	}
+
+	r := readline.Runes{}
+	var wrapped []string
+	for _, line := range lines {
+		start, w := 0, 0
+		for i, ch := range line {
+			cw := r.Width(ch)
+			if w+cw > width {
+				wrapped = append(wrapped, line[start:i])
+				start, w = i, 0
+			}
+			w += cw
+		}
+		wrapped = append(wrapped, line[start:])
+	}
+	return wrapped
+}
+
+// printTable 按当前边框样式（\pset border）打印表头与数据行；\pset tuples_only 时只打印数据行
+//
+//	border 0: 列之间仅以空格分隔
+//	border 1: 列之间以 | 分隔，表头下方有分隔线（默认）
+//	border 2: 在 1 的基础上为整个表格加外框
+func (c *CLI) printTable(cols []string, colWidths []int, rows [][]string) {
+	c.printTitle(tableWidth(colWidths, c.settings.Border))
+
+	if c.settings.Border == 2 {
+		c.printRule(colWidths)
+	}
+
+	if !c.settings.TuplesOnly {
+		header := make([]string, len(cols))
+		for i, col := range cols {
+			header[i] = c.style(ansiBold, col)
+		}
+		c.printRow(header, colWidths)
+		c.printRule(colWidths)
+	}
+
+	for _, row := range rows {
+		c.printRow(row, colWidths)
+	}
+
+	if c.settings.Border == 2 {
+		c.printRule(colWidths)
+	}
+}
+
+// printRow 打印一行单元格
+func (c *CLI) printRow(cells []string, colWidths []int) {
+	var sb strings.Builder
+	left, sep, right := " ", " | ", " "
+	switch c.settings.Border {
+	case 0:
+		left, sep, right = "", " ", ""
+	case 2:
+		left, sep, right = "| ", " | ", " |"
+	}
+
+	sb.WriteString(left)
+	for i, cell := range cells {
+		if i > 0 {
+			sb.WriteString(sep)
+		}
+		// 最后一列在无右边框时不补齐空格；按显示宽度补齐
+		sb.WriteString(cell)
+		if i < len(cells)-1 || right != "" {
+			if pad := colWidths[i] - displayWidth(cell); pad > 0 {
+				sb.WriteString(strings.Repeat(" ", pad))
+			}
+		}
+	}
+	sb.WriteString(right)
+	fmt.Fprintf(c.out, "%s\n", sb.String())
+}
+
+// printRule 打印表头分隔线或外框线
+func (c *CLI) printRule(colWidths []int) {
+	var sb strings.Builder
+	switch c.settings.Border {
+	case 0:
+		for i, width := range colWidths {
+			if i > 0 {
+				sb.WriteString(" ")
+			}
+			sb.WriteString(strings.Repeat("-", width))
+		}
+	case 2:
+		sb.WriteString("+")
+		for _, width := range colWidths {
+			sb.WriteString(strings.Repeat("-", width+2))
+			sb.WriteString("+")
+		}
+	default:
+		for i, width := range colWidths {
+			if i > 0 {
+				sb.WriteString("+")
+			}
+			sb.WriteString(strings.Repeat("-", width+2))
+		}
+	}
+	fmt.Fprintf(c.out, "%s\n", sb.String())
+}
+
+// printTitle 打印 \C 设置的标题，在给定宽度内居中；\pset tuples_only 时不打印
+func (c *CLI) printTitle(width int) {
+	if c.settings.Title == "" || c.settings.TuplesOnly {
+		return
+	}
+	pad := 0
+	if n := displayWidth(c.settings.Title); n < width {
+		pad = (width - n) / 2
+	}
+	fmt.Fprintf(c.out, "%s%s\n", strings.Repeat(" ", pad), c.settings.Title)
+}
+
+// tableWidth 计算给定边框样式下表格的总宽度
+func tableWidth(colWidths []int, border int) int {
+	width := 0
+	for _, w := range colWidths {
+		width += w
+	}
+	n := len(colWidths)
+	switch border {
+	case 0:
+		return width + n - 1
+	case 2:
+		return width + 3*n + 1
+	default:
+		return width + 3*n - 1
+	}
+}
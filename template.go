@@ -0,0 +1,106 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/lib/pq"
+)
+
+// templateData 传给输出模板（\pset format template）的数据
+type templateData struct {
+	Columns []string                 // 列名
+	Rows    [][]interface{}          // 各行的值，按显示设置格式化为字符串，NULL 为 nil
+	Records []map[string]interface{} // 与 Rows 相同，以列名为键
+}
+
+// templateFuncs 输出模板可用的辅助函数
+var templateFuncs = template.FuncMap{
+	// quote 将值写成 SQL 字面量，nil 写作 NULL
+	"quote": func(v interface{}) string {
+		if v == nil {
+			return "NULL"
+		}
+		return pq.QuoteLiteral(fmt.Sprint(v))
+	},
+	// ident 将名称写成带引号的 SQL 标识符
+	"ident": func(name string) string {
+		return pq.QuoteIdentifier(name)
+	},
+	// default 值为 nil 或空串时返回 def，用法 {{default "n/a" .value}}
+	"default": func(def, v interface{}) interface{} {
+		if v == nil || v == "" {
+			return def
+		}
+		return v
+	},
+	// join 以 sep 连接各元素，nil 视为空串
+	"join": func(sep string, items interface{}) string {
+		var parts []string
+		switch items := items.(type) {
+		case []string:
+			parts = items
+		case []interface{}:
+			for _, item := range items {
+				if item == nil {
+					item = ""
+				}
+				parts = append(parts, fmt.Sprint(item))
+			}
+		default:
+			return fmt.Sprint(items)
+		}
+		return strings.Join(parts, sep)
+	},
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+}
+
+// displayTemplate 以 \set templatefile 指定的 Go text/template 输出结果，返回行数
+// 与 CSV 一样用于导出，不受 maxrows 限制，也不输出标题与行数
+func (c *CLI) displayTemplate(rows *sql.Rows, cols []string, colTypes []*sql.ColumnType) (int, error) {
+	path := c.vars["templatefile"]
+	if path == "" {
+		return 0, fmt.Errorf("no template file: use \\set templatefile PATH")
+	}
+	text, err := os.ReadFile(path)
+	if err != nil {
+		return 0, fmt.Errorf("could not read template file \"%s\": %w", path, err)
+	}
+	tmpl, err := template.New(path).Funcs(templateFuncs).Parse(string(text))
+	if err != nil {
+		return 0, err
+	}
+
+	data := templateData{Columns: cols}
+	for rows.Next() {
+		vals := make([]interface{}, len(cols))
+		valPtrs := make([]interface{}, len(cols))
+		for i := range vals {
+			valPtrs[i] = &vals[i]
+		}
+		rows.Scan(valPtrs...)
+
+		row := make([]interface{}, len(vals))
+		record := make(map[string]interface{}, len(vals))
+		for i, v := range vals {
+			var colType *sql.ColumnType
+			if i < len(colTypes) {
+				colType = colTypes[i]
+			}
+			if v != nil {
+				row[i] = c.formatValue(v, colType)
+			}
+			record[cols[i]] = row[i]
+		}
+		data.Rows = append(data.Rows, row)
+		data.Records = append(data.Records, record)
+	}
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	return len(data.Rows), tmpl.Execute(c.out, data)
+}
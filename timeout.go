@@ -0,0 +1,80 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultQueryTimeout 客户端等待普通语句的最长时间（维护命令不受限制）
+const defaultQueryTimeout = 60 * time.Second
+
+// parseStatementTimeout 解析语句超时：Go 时长（5s、500ms、2m）或毫秒数（与服务器相同），0、off 或空值表示不限制
+func parseStatementTimeout(value string) (time.Duration, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", "0", "off":
+		return 0, nil
+	}
+	if ms, err := strconv.ParseInt(value, 10, 64); err == nil && ms >= 0 {
+		return time.Duration(ms) * time.Millisecond, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid statement timeout \"%s\": use a duration such as 5s or 500ms, a number of milliseconds, or 0 for none", value)
+	}
+	// 服务器以毫秒为单位，更短的时长会被截断为 0，即不限制
+	if d > 0 && d < time.Millisecond {
+		return 0, fmt.Errorf("invalid statement timeout \"%s\": the minimum is 1ms (use 0 for none)", value)
+	}
+	return d, nil
+}
+
+// setStatementTimeout 处理 \set STATEMENT_TIMEOUT：在当前会话连接上执行 SET statement_timeout，
+// 并记入 Config.StatementTimeout，\c 重新连接后继续生效
+func (c *CLI) setStatementTimeout(value string) error {
+	d, err := parseStatementTimeout(value)
+	if err != nil {
+		return err
+	}
+	if c.conn != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if _, err := c.conn.ExecContext(ctx, fmt.Sprintf("SET statement_timeout = %d", d.Milliseconds())); err != nil {
+			return redactError(err)
+		}
+	}
+	c.config.StatementTimeout = d
+	return nil
+}
+
+// syncStatementTimeout 在 SET/RESET statement_timeout 执行成功后读取服务器的当前值，
+// 使 Config.StatementTimeout 与 STATEMENT_TIMEOUT 变量保持一致；SET LOCAL 只在当前事务中生效，不同步
+func (c *CLI) syncStatementTimeout(sqlStr string) {
+	words := topLevelWords(stripLeadingComments(sqlStr))
+	if len(words) < 2 || (words[0] != "SET" && words[0] != "RESET") || words[1] == "LOCAL" {
+		return
+	}
+	if !strings.Contains(strings.ToLower(sqlStr), "statement_timeout") {
+		return
+	}
+
+	var ms int64
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := c.conn.QueryRowContext(ctx, "SELECT setting::bigint FROM pg_catalog.pg_settings WHERE name = 'statement_timeout'").Scan(&ms); err != nil {
+		return
+	}
+	c.config.StatementTimeout = time.Duration(ms) * time.Millisecond
+	c.vars["STATEMENT_TIMEOUT"] = c.config.StatementTimeout.String()
+}
+
+// queryTimeout 返回客户端等待普通语句的时间：默认 60 秒，语句超时更长时以其为准，
+// 并多留一秒，使服务器先报告 statement timeout 错误
+func (c *CLI) queryTimeout() time.Duration {
+	if c.config.StatementTimeout >= defaultQueryTimeout {
+		return c.config.StatementTimeout + time.Second
+	}
+	return defaultQueryTimeout
+}
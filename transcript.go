@@ -0,0 +1,91 @@
+package postgres
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// transcriptTerminal 将写入终端的内容同时写入会话记录文件
+type transcriptTerminal struct {
+	Terminal
+	file *os.File
+}
+
+// Write 写入终端与记录文件，记录文件的写入错误被忽略
+func (t *transcriptTerminal) Write(p []byte) (int, error) {
+	n, err := t.Terminal.Write(p)
+	t.file.Write(p[:n])
+	return n, err
+}
+
+// handleScript 处理 \script：
+//
+//	\script FILE      开始记录会话到 FILE（覆盖）
+//	\script -a FILE   开始记录会话，追加到 FILE
+//	\script           停止记录
+func (c *CLI) handleScript(args []string) {
+	if len(args) == 0 {
+		if c.transcript == nil {
+			fmt.Fprintf(c.term, "Not recording.\n")
+			return
+		}
+		path := c.transcript.file.Name()
+		c.stopTranscript()
+		fmt.Fprintf(c.term, "Session recording stopped, saved to \"%s\".\n", path)
+		return
+	}
+
+	appendMode := args[0] == "-a"
+	if appendMode {
+		args = args[1:]
+	}
+	if len(args) == 0 {
+		fmt.Fprintf(c.term, "\\script: missing file name\n")
+		return
+	}
+	path := strings.Join(args, " ")
+	if err := c.startTranscript(path, appendMode); err != nil {
+		fmt.Fprintf(c.term, "%v\n", err)
+		return
+	}
+	fmt.Fprintf(c.term, "Recording session to \"%s\"; \\script without arguments stops.\n", path)
+}
+
+// startTranscript 开始将会话（提示符、输入与全部输出）记录到文件，类似 Unix 的 script 命令
+// 已在记录时先停止之前的记录；密码输入不被记录
+func (c *CLI) startTranscript(path string, appendMode bool) error {
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	if appendMode {
+		flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	}
+	f, err := os.OpenFile(path, flags, 0600)
+	if err != nil {
+		return fmt.Errorf("could not open transcript file \"%s\": %w", path, err)
+	}
+
+	c.stopTranscript()
+	c.transcript = &transcriptTerminal{Terminal: c.term, file: f}
+	c.swapTerminal(c.transcript)
+	c.reader.SetTranscript(f)
+	return nil
+}
+
+// stopTranscript 停止记录会话并关闭记录文件
+func (c *CLI) stopTranscript() {
+	if c.transcript == nil {
+		return
+	}
+	c.reader.SetTranscript(nil)
+	c.swapTerminal(c.transcript.Terminal)
+	c.transcript.file.Close()
+	c.transcript = nil
+}
+
+// swapTerminal 替换输出终端，\o 未重定向时查询结果随之输出到新终端
+func (c *CLI) swapTerminal(term Terminal) {
+	if c.out == c.term {
+		c.out = term
+	}
+	c.term = term
+}
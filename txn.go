@@ -0,0 +1,225 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// trackTransaction 根据语句的执行结果更新事务状态
+// 事务块中的服务器错误（包括 Ctrl-C 取消）会使事务进入失败状态，提示符 %x 显示 !；
+// 之后的语句成功执行说明事务已恢复（如 ROLLBACK TO SAVEPOINT）
+// 在失败的事务中继续执行语句（SQLSTATE 25P02）时，交互模式下询问是否立即回滚
+func (c *CLI) trackTransaction(err error) {
+	if !c.inTransaction {
+		c.txnFailed, c.savepoints = false, nil
+		return
+	}
+	if err == nil {
+		c.txnFailed = false
+		return
+	}
+
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		// 客户端拒绝执行的语句（只读模式等）不影响事务
+		return
+	}
+	c.txnFailed = true
+	if pqErr.Code == "25P02" && !c.singleTxn && c.reader.Interactive() {
+		c.offerRollback()
+	}
+}
+
+// offerRollback 询问是否回滚失败的事务，回答 y 时执行 ROLLBACK
+func (c *CLI) offerRollback() {
+	c.reader.SetPrompt("The current transaction is aborted. Roll it back now? (y/N) ")
+	answer, err := c.reader.ReadLine()
+	if err != nil {
+		return
+	}
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+	default:
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if _, err := c.conn.ExecContext(ctx, "ROLLBACK"); err != nil {
+		c.printError(err)
+		return
+	}
+	c.inTransaction, c.txnFailed, c.savepoints = false, false, nil
+	fmt.Fprintf(c.term, "ROLLBACK\n")
+}
+
+// endOpenTransaction 在会话结束时处理未结束的事务，避免关闭连接时静默回滚
+// ask 为 true 且为交互模式时询问是否提交（失败的事务只能回滚），否则回滚并输出警告
+func (c *CLI) endOpenTransaction(ask bool) {
+	if !c.inTransaction || c.conn == nil {
+		return
+	}
+
+	stmt := "ROLLBACK"
+	if ask && !c.txnFailed && c.reader.Interactive() {
+		c.reader.SetPrompt("There is an open transaction. Commit it before quitting? (y/N) ")
+		answer, _ := c.reader.ReadLine()
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "y", "yes":
+			stmt = "COMMIT"
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if _, err := c.conn.ExecContext(ctx, stmt); err != nil {
+		c.printError(err)
+		return
+	}
+	c.inTransaction, c.txnFailed, c.savepoints = false, false, nil
+	if stmt == "COMMIT" {
+		fmt.Fprintf(c.term, "COMMIT\n")
+		return
+	}
+	fmt.Fprintf(c.term, "%s\n", c.style(ansiYellow, "WARNING:  there was a transaction in progress; it has been rolled back"))
+}
+
+// endTransaction 执行 COMMIT、ROLLBACK 或 PREPARE TRANSACTION 并更新事务状态
+// 不在事务中时服务器发出 "there is no transaction in progress" 警告（由 printNotice 输出）；
+// AND CHAIN 在结束事务后立即开始新事务，事务保持进行中；
+// 语句有语法错误（SQLSTATE 42 类）时没有执行，事务仍然打开，其他错误时事务已由服务器结束
+func (c *CLI) endTransaction(sqlStr, txn string, startTime time.Time) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if _, err := c.conn.ExecContext(ctx, sqlStr); err != nil {
+		var pqErr *pq.Error
+		if !errors.As(err, &pqErr) || pqErr.Code.Class() != "42" {
+			c.inTransaction = false
+		}
+		c.printError(err)
+		return err
+	}
+
+	// 失败的事务在 COMMIT 或 PREPARE TRANSACTION 时由服务器回滚
+	tag := txn
+	if c.txnFailed {
+		tag = "ROLLBACK"
+	}
+	c.inTransaction, c.txnFailed, c.savepoints = chainsTransaction(sqlStr), false, nil
+	c.lastTag = tag
+	fmt.Fprintf(c.out, "%s\n", tag)
+	c.printTiming(time.Since(startTime))
+	return nil
+}
+
+// chainsTransaction 判断 COMMIT/ROLLBACK 是否带 AND CHAIN（AND NO CHAIN 除外）
+func chainsTransaction(sqlStr string) bool {
+	words := topLevelWords(stripLeadingComments(sqlStr))
+	return containsWord(words, "CHAIN") && !containsWord(words, "NO")
+}
+
+// transactionCommand 判断语句是否开始或结束事务，返回 BEGIN、COMMIT、ROLLBACK、PREPARE TRANSACTION 或空串
+// START TRANSACTION 视为 BEGIN，END 视为 COMMIT，ABORT 视为 ROLLBACK；
+// ROLLBACK TO SAVEPOINT 以及两阶段提交的 COMMIT/ROLLBACK PREPARED 不改变当前事务状态，返回空串
+func transactionCommand(sqlStr string) string {
+	words := topLevelWords(stripLeadingComments(sqlStr))
+	if len(words) == 0 {
+		return ""
+	}
+
+	switch words[0] {
+	case "BEGIN":
+		return "BEGIN"
+	case "START":
+		if len(words) > 1 && words[1] == "TRANSACTION" {
+			return "BEGIN"
+		}
+	case "COMMIT", "END":
+		if !containsWord(words, "PREPARED") {
+			return "COMMIT"
+		}
+	case "ROLLBACK", "ABORT":
+		if !containsWord(words, "TO") && !containsWord(words, "PREPARED") {
+			return "ROLLBACK"
+		}
+	case "PREPARE":
+		// PREPARE TRANSACTION 将当前事务交给两阶段提交，会话随之退出事务
+		if len(words) > 1 && words[1] == "TRANSACTION" {
+			return "PREPARE TRANSACTION"
+		}
+	}
+	return ""
+}
+
+// trackSavepoint 根据执行成功的 SAVEPOINT、RELEASE、ROLLBACK TO 更新保存点栈
+// 同名保存点可以重复定义，RELEASE 与 ROLLBACK TO 作用于最近定义的一个
+func (c *CLI) trackSavepoint(sqlStr string) {
+	words := topLevelWords(stripLeadingComments(sqlStr))
+	if len(words) == 0 || !c.inTransaction {
+		return
+	}
+	name := lastIdentifier(sqlStr)
+
+	switch {
+	case words[0] == "SAVEPOINT":
+		c.savepoints = append(c.savepoints, name)
+	case words[0] == "RELEASE":
+		if i := lastIndex(c.savepoints, name); i >= 0 {
+			c.savepoints = c.savepoints[:i]
+		}
+	case (words[0] == "ROLLBACK" || words[0] == "ABORT") && containsWord(words, "TO"):
+		// 回滚到保存点后该保存点仍然存在
+		if i := lastIndex(c.savepoints, name); i >= 0 {
+			c.savepoints = c.savepoints[:i+1]
+		}
+	}
+}
+
+// lastIdentifier 返回语句的最后一个标识符（未加引号时转为小写）
+func lastIdentifier(sqlStr string) string {
+	sqlStr = strings.TrimSpace(sqlStr)
+	if strings.HasSuffix(sqlStr, "\"") {
+		// 向前找到与之配对的引号，"" 表示引号本身
+		for i := len(sqlStr) - 2; i >= 0; i-- {
+			if sqlStr[i] != '"' {
+				continue
+			}
+			if i > 0 && sqlStr[i-1] == '"' {
+				i--
+				continue
+			}
+			return strings.ReplaceAll(sqlStr[i+1:len(sqlStr)-1], "\"\"", "\"")
+		}
+	}
+	fields := strings.Fields(sqlStr)
+	return strings.ToLower(fields[len(fields)-1])
+}
+
+// lastIndex 返回 name 在列表中最后一次出现的位置，不存在时返回 -1
+func lastIndex(list []string, name string) int {
+	for i := len(list) - 1; i >= 0; i-- {
+		if list[i] == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// showSavepoints 列出当前事务中的保存点（\sp），外层在前
+func (c *CLI) showSavepoints() {
+	switch {
+	case !c.inTransaction:
+		fmt.Fprintf(c.term, "Not in a transaction block.\n")
+	case len(c.savepoints) == 0:
+		fmt.Fprintf(c.term, "No savepoints.\n")
+	default:
+		for i, name := range c.savepoints {
+			fmt.Fprintf(c.term, "%s%s\n", strings.Repeat("  ", i), name)
+		}
+	}
+}
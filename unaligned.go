@@ -0,0 +1,50 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// displayUnaligned 以非对齐格式输出结果（\pset format unaligned 或 \a）：每行的值以 \pset fieldsep 分隔，返回行数
+// 与 psql 一致，首行为列名，末尾为行数，\pset tuples_only 时都省略；用于脚本处理，不受 \x 与 maxrows 影响
+func (c *CLI) displayUnaligned(rows *sql.Rows, cols []string, colTypes []*sql.ColumnType) int {
+	sep := c.settings.FieldSep
+	if !c.settings.TuplesOnly {
+		if c.settings.Title != "" {
+			fmt.Fprintf(c.out, "%s\n", c.settings.Title)
+		}
+		io.WriteString(c.out, strings.Join(cols, sep)+"\n")
+	}
+
+	rowCount := 0
+	for rows.Next() {
+		rowCount++
+		vals := make([]interface{}, len(cols))
+		valPtrs := make([]interface{}, len(cols))
+		for i := range vals {
+			valPtrs[i] = &vals[i]
+		}
+		rows.Scan(valPtrs...)
+
+		fields := make([]string, len(vals))
+		for i, v := range vals {
+			var colType *sql.ColumnType
+			if i < len(colTypes) {
+				colType = colTypes[i]
+			}
+			fields[i] = c.formatValue(v, colType)
+		}
+		io.WriteString(c.out, strings.Join(fields, sep)+"\n")
+	}
+
+	if !c.settings.TuplesOnly {
+		if rowCount == 1 {
+			fmt.Fprintf(c.out, "(1 row)\n")
+		} else {
+			fmt.Fprintf(c.out, "(%d rows)\n", rowCount)
+		}
+	}
+	return rowCount
+}
@@ -0,0 +1,82 @@
+package postgres
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+)
+
+// modulePath 本包所在模块的路径，用于从构建信息中查找版本
+const modulePath = "binrc.com/dbcli/postgres-cli"
+
+// Version 返回本包的版本（如 v1.4.0），取自调用方程序的构建信息
+// 作为主模块构建或版本未知时返回 "(devel)"
+func Version() string {
+	return moduleVersion(modulePath)
+}
+
+// moduleVersion 返回构建信息中模块的版本，未找到时返回 "(devel)"
+func moduleVersion(path string) string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "(devel)"
+	}
+	if info.Main.Path == path && info.Main.Version != "" {
+		return info.Main.Version
+	}
+	for _, dep := range info.Deps {
+		if dep.Path == path {
+			if dep.Replace != nil && dep.Replace.Version != "" {
+				return dep.Replace.Version
+			}
+			return dep.Version
+		}
+	}
+	return "(devel)"
+}
+
+// showVersion 输出客户端与服务器的版本（\version）
+func (c *CLI) showVersion() {
+	fmt.Fprintf(c.term, "Client: postgres-cli %s (lib/pq %s, %s)\n", Version(), moduleVersion("github.com/lib/pq"), runtime.Version())
+	if c.serverInfo.Version == "" {
+		fmt.Fprintf(c.term, "Server: unknown (not connected)\n")
+		return
+	}
+	fmt.Fprintf(c.term, "Server: PostgreSQL %s (server_version_num %d)\n", extractVersionNumber(c.serverInfo.Version), c.serverInfo.VersionNum)
+}
+
+// ServerInfo 返回已连接服务器的信息
+func (c *CLI) ServerInfo() ServerInfo {
+	return c.serverInfo
+}
+
+// AtLeast 判断服务器版本是否不低于 major.minor
+// 版本未知（未连接或获取失败）时返回 false
+func (s ServerInfo) AtLeast(major, minor int) bool {
+	if s.VersionNum == 0 {
+		return false
+	}
+	if s.Major != major {
+		return s.Major > major
+	}
+	return s.Minor >= minor
+}
+
+// splitVersionNum 将 server_version_num 拆分为主、次版本号
+// 10 及以上：160002 -> 16.2；10 以下：90624 -> 9.6
+func splitVersionNum(num int) (major, minor int) {
+	if num >= 100000 {
+		return num / 10000, num % 10000
+	}
+	return num / 10000, num / 100 % 100
+}
+
+// requireVersion 检查服务器版本是否支持某个命令，不支持时输出提示并返回 false
+// 版本未知时不拦截，由服务器报告错误
+func (c *CLI) requireVersion(major, minor int, command string) bool {
+	if c.serverInfo.VersionNum == 0 || c.serverInfo.AtLeast(major, minor) {
+		return true
+	}
+	fmt.Fprintf(c.term, "The server (version %s) does not support %s.\n\n", extractVersionNumber(c.serverInfo.Version), command)
+	return false
+}
@@ -0,0 +1,127 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// waitforInterval \waitfor 检查条件的间隔
+const waitforInterval = time.Second
+
+// parseWaitforArgs 解析 \waitfor "QUERY" [TIMEOUT] 或 \waitfor QUERY
+// 查询用双引号括起（"" 表示一个双引号）时可在其后指定超时秒数；未加引号时整行都是查询，不设超时
+func parseWaitforArgs(args string) (query string, timeout time.Duration, err error) {
+	args = strings.TrimSpace(args)
+	if !strings.HasPrefix(args, "\"") {
+		return args, 0, nil
+	}
+
+	var b strings.Builder
+	i := 1
+	for ; i < len(args); i++ {
+		if args[i] == '"' {
+			if i+1 < len(args) && args[i+1] == '"' {
+				b.WriteByte('"')
+				i++
+				continue
+			}
+			break
+		}
+		b.WriteByte(args[i])
+	}
+	if i >= len(args) {
+		return "", 0, errors.New("\\waitfor: unterminated quoted query")
+	}
+
+	switch rest := strings.Fields(args[i+1:]); len(rest) {
+	case 0:
+	case 1:
+		sec, err := strconv.ParseFloat(rest[0], 64)
+		if err != nil || sec <= 0 {
+			return "", 0, fmt.Errorf("\\waitfor: incorrect timeout value \"%s\"", rest[0])
+		}
+		timeout = time.Duration(sec * float64(time.Second))
+	default:
+		return "", 0, errors.New("\\waitfor: too many arguments")
+	}
+	return b.String(), timeout, nil
+}
+
+// waitfor 处理 \waitfor：每秒执行一次返回布尔值的查询，直到结果为 true、超时或按下 Ctrl-C
+// 等待期间每次检查输出一个点；超时、取消或查询出错时返回错误，ON_ERROR_STOP 开启时脚本随之停止
+func (c *CLI) waitfor(args string) error {
+	query, timeout, err := parseWaitforArgs(args)
+	if err == nil && query == "" {
+		err = errors.New("\\waitfor: missing query")
+	}
+	if err != nil {
+		fmt.Fprintf(c.term, "%v\n", err)
+		return err
+	}
+	query = c.interpolate(query)
+	if err := c.checkReadOnly(query); err != nil {
+		c.printError(err)
+		return err
+	}
+
+	ctx := context.Background()
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	ctx, stopInterrupt := interruptContext(ctx)
+	defer stopInterrupt()
+
+	start := time.Now()
+	dots := false
+	for {
+		done, err := c.checkCondition(ctx, query)
+		switch {
+		case ctx.Err() == context.DeadlineExceeded:
+			err = fmt.Errorf("\\waitfor: condition not met after %s", formatDuration(time.Since(start)))
+		case ctx.Err() != nil:
+			err = errors.New("\\waitfor: cancelled")
+		}
+		if err != nil {
+			if dots {
+				fmt.Fprintf(c.term, "\n")
+			}
+			c.printError(err)
+			return err
+		}
+		if done {
+			if dots {
+				fmt.Fprintf(c.term, "\n")
+			}
+			fmt.Fprintf(c.term, "Condition met after %s.\n", formatDuration(time.Since(start)))
+			return nil
+		}
+
+		fmt.Fprintf(c.term, ".")
+		dots = true
+		select {
+		case <-ctx.Done():
+		case <-time.After(waitforInterval):
+		}
+	}
+}
+
+// checkCondition 执行一次条件查询，取第一行第一列，NULL 或无结果视为 false
+func (c *CLI) checkCondition(ctx context.Context, query string) (bool, error) {
+	qctx, cancel := context.WithTimeout(ctx, c.queryTimeout())
+	defer cancel()
+
+	var done sql.NullBool
+	err := c.conn.QueryRowContext(qctx, query).Scan(&done)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	c.trackTransaction(err)
+	return done.Bool, err
+}
@@ -0,0 +1,113 @@
+package postgres
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// watchOptions \watch 的参数
+type watchOptions struct {
+	interval time.Duration // 执行间隔，默认 2 秒
+	count    int           // 执行次数，0 表示直到按下回车
+	diff     bool          // 高亮与上一次结果不同的单元格
+}
+
+// parseWatchArgs 解析 \watch [-d] [i=]SEC [c=N]
+func parseWatchArgs(args []string) (watchOptions, error) {
+	opts := watchOptions{interval: 2 * time.Second}
+	for _, arg := range args {
+		name, value := "i", arg
+		if i := strings.IndexByte(arg, '='); i >= 0 {
+			name, value = arg[:i], arg[i+1:]
+		}
+		switch {
+		case arg == "-d":
+			opts.diff = true
+		case name == "i" || name == "interval":
+			sec, err := strconv.ParseFloat(value, 64)
+			if err != nil || sec <= 0 {
+				return opts, fmt.Errorf("\\watch: incorrect interval value \"%s\"", value)
+			}
+			opts.interval = time.Duration(sec * float64(time.Second))
+		case name == "c" || name == "count":
+			n, err := strconv.Atoi(value)
+			if err != nil || n <= 0 {
+				return opts, fmt.Errorf("\\watch: incorrect count value \"%s\"", value)
+			}
+			opts.count = n
+		default:
+			return opts, fmt.Errorf("\\watch: unrecognized parameter \"%s\"", arg)
+		}
+	}
+	return opts, nil
+}
+
+// watch 处理 \watch：按间隔重复执行最近一次的 SQL，直到达到次数、出错或按下回车
+// 交互模式下未指定次数时由回车结束；非交互模式必须指定次数，以免读取后续输入
+func (c *CLI) watch(args []string) {
+	if c.lastQuery == "" {
+		fmt.Fprintf(c.term, "\\watch cannot be used with an empty query\n")
+		return
+	}
+	opts, err := parseWatchArgs(args)
+	if err != nil {
+		fmt.Fprintf(c.term, "%v\n", err)
+		return
+	}
+
+	var stop chan struct{}
+	if opts.count == 0 {
+		if !c.reader.Interactive() {
+			fmt.Fprintf(c.term, "\\watch: a count (c=N) is required when input is not a terminal\n")
+			return
+		}
+		stop = make(chan struct{})
+		c.reader.SetPrompt("")
+		go func() {
+			c.reader.ReadLine()
+			close(stop)
+		}()
+		fmt.Fprintf(c.term, "(press Enter to stop)\n")
+	}
+
+	// 差异高亮依赖颜色输出；重复执行时不分页
+	c.watchDiff, c.watching = opts.diff && c.color, true
+	defer func() {
+		c.watchDiff, c.watchPrev, c.watchCurr, c.watching = false, nil, nil, false
+	}()
+
+	for i := 1; ; i++ {
+		fmt.Fprintf(c.term, "%s (every %gs)\n\n", time.Now().Format("Mon Jan 2 15:04:05 2006"), opts.interval.Seconds())
+		err := c.runSQL(c.lastQuery)
+		c.watchPrev, c.watchCurr = c.watchCurr, nil
+		if err != nil {
+			if stop != nil {
+				fmt.Fprintf(c.term, "\\watch stopped; press Enter to continue\n")
+				<-stop
+			}
+			return
+		}
+		if opts.count > 0 && i >= opts.count {
+			return
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-time.After(opts.interval):
+		}
+	}
+}
+
+// watchChanged 判断 \watch 结果中第 row 行第 col 列的值与上一次相比是否变化（第一次执行不高亮）
+func (c *CLI) watchChanged(row, col int, value string) bool {
+	if c.watchPrev == nil {
+		return false
+	}
+	if row >= len(c.watchPrev) || col >= len(c.watchPrev[row]) {
+		return true
+	}
+	return c.watchPrev[row][col] != value
+}